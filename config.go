@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+// config holds the settings of shonenjump.
+// They are read from the config file and can be overridden by environment variables.
+type config struct {
+	DataPath           string             `toml:"data_path"`
+	Scoring            string             `toml:"scoring"`
+	Weight             float64            `toml:"weight"`
+	AgingRate          float64            `toml:"aging_rate"`
+	MaxCompleteOptions int                `toml:"max_complete_options"`
+	Excludes           []string           `toml:"excludes"`
+	Matchers           []string           `toml:"matchers"`
+	Ranking            string             `toml:"ranking"`
+	MatchWeights       map[string]float64 `toml:"match_weights"`
+}
+
+func homeDir() string {
+	usr, _ := user.Current()
+	return usr.HomeDir
+}
+
+func defaultDataPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".local/share")
+	}
+	return filepath.Join(dir, "shonenjump", "shonenjump.txt")
+}
+
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(homeDir(), ".config")
+	}
+	return filepath.Join(dir, "shonenjump", "config.toml")
+}
+
+func defaultConfig() config {
+	return config{
+		DataPath:           defaultDataPath(),
+		Scoring:            jump.ScoringClassic,
+		Weight:             jump.DefaultWeight,
+		AgingRate:          jump.DefaultAgingRate,
+		MaxCompleteOptions: jump.MaxCompleteOptions,
+		Matchers:           append([]string(nil), jump.DefaultMatchers...),
+		Ranking:            jump.RankingPriority,
+		MatchWeights:       defaultMatchWeights(),
+	}
+}
+
+func defaultMatchWeights() map[string]float64 {
+	weights := make(map[string]float64, len(jump.DefaultMatchWeights))
+	for name, w := range jump.DefaultMatchWeights {
+		weights[name] = w
+	}
+	return weights
+}
+
+// loadConfig reads the settings from the config file at path, or from the default
+// location if path is empty, and applies the overrides from environment variables.
+// Only a config file given explicitly has to exist.
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		path = os.Getenv("SHONENJUMP_CONFIG")
+	}
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	meta, err := toml.DecodeFile(path, &cfg)
+	if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+		return cfg, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("unknown setting in %s: %v", path, undecoded[0])
+	}
+
+	if err := cfg.applyEnv(os.Getenv); err != nil {
+		return cfg, err
+	}
+	cfg.DataPath = expandHome(cfg.DataPath)
+	return cfg, cfg.validate()
+}
+
+// applyEnv overrides settings with the SHONENJUMP_* environment variables that are set.
+func (cfg *config) applyEnv(getenv func(string) string) error {
+	if v := getenv("SHONENJUMP_DATA_PATH"); v != "" {
+		cfg.DataPath = v
+	}
+	if v := getenv("SHONENJUMP_SCORING"); v != "" {
+		cfg.Scoring = v
+	}
+	if v := getenv("SHONENJUMP_WEIGHT"); v != "" {
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SHONENJUMP_WEIGHT: %w", err)
+		}
+		cfg.Weight = weight
+	}
+	if v := getenv("SHONENJUMP_AGING_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SHONENJUMP_AGING_RATE: %w", err)
+		}
+		cfg.AgingRate = rate
+	}
+	if v := getenv("SHONENJUMP_MAX_COMPLETE_OPTIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid SHONENJUMP_MAX_COMPLETE_OPTIONS: %w", err)
+		}
+		cfg.MaxCompleteOptions = n
+	}
+	if v := getenv("SHONENJUMP_EXCLUDE"); v != "" {
+		cfg.Excludes = jump.SplitExcludes(v)
+	}
+	if v := getenv("SHONENJUMP_MATCHERS"); v != "" {
+		cfg.Matchers = strings.Split(v, ",")
+	}
+	if v := getenv("SHONENJUMP_RANKING"); v != "" {
+		cfg.Ranking = v
+	}
+	return nil
+}
+
+func (cfg config) validate() error {
+	if cfg.Weight <= 0 {
+		return fmt.Errorf("weight must be positive: %v", cfg.Weight)
+	}
+	if cfg.AgingRate <= 0 || cfg.AgingRate >= 1 {
+		return fmt.Errorf("aging_rate must be between 0 and 1: %v", cfg.AgingRate)
+	}
+	if cfg.MaxCompleteOptions <= 0 {
+		return fmt.Errorf("max_complete_options must be positive: %v", cfg.MaxCompleteOptions)
+	}
+	_, err := cfg.storeOptions()
+	return err
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir(), path[1:])
+	}
+	return path
+}
+
+func (cfg config) scorer() (jump.Scorer, error) {
+	if cfg.Scoring == jump.ScoringClassic {
+		return jump.ClassicScorer{AgingRate: cfg.AgingRate}, nil
+	}
+	return jump.NewScorer(cfg.Scoring)
+}
+
+func (cfg config) finder() (jump.Finder, error) {
+	scorer, err := cfg.scorer()
+	if err != nil {
+		return jump.Finder{}, err
+	}
+	finder, err := jump.NewFinder(cfg.Matchers)
+	if err != nil {
+		return finder, err
+	}
+	finder, err = finder.WithRanking(cfg.Ranking, cfg.MatchWeights)
+	return finder.WithScorer(scorer), err
+}
+
+func (cfg config) storeOptions() ([]jump.Option, error) {
+	scorer, err := cfg.scorer()
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := jump.ParseExcludes(cfg.Excludes)
+	if err != nil {
+		return nil, err
+	}
+	finder, err := cfg.finder()
+	if err != nil {
+		return nil, err
+	}
+	return []jump.Option{
+		jump.WithScorer(scorer),
+		jump.WithWeight(cfg.Weight),
+		jump.WithExcludes(excludes),
+		jump.WithFinder(finder),
+	}, nil
+}
+
+// newStore creates the store of the data file, making sure its folder exists.
+// opts are applied after the options of the config.
+func (cfg config) newStore(opts ...jump.Option) (jump.Store, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.DataPath), 0740); err != nil {
+		return jump.Store{}, err
+	}
+	cfgOpts, err := cfg.storeOptions()
+	if err != nil {
+		return jump.Store{}, err
+	}
+	return jump.NewStore(cfg.DataPath, append(cfgOpts, opts...)...), nil
+}
+
+func (cfg config) write(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(cfg)
+}
+
+func runConfig(cfg config, args []string) error {
+	if len(args) != 1 || args[0] != "show" {
+		return fmt.Errorf("usage: shonenjump config show")
+	}
+	return cfg.write(os.Stdout)
+}
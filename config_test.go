@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// clearEnv unsets the SHONENJUMP_* variables of the environment running the tests,
+// which would override the settings tested.
+func clearEnv(t *testing.T) {
+	for _, name := range []string{
+		"SHONENJUMP_CONFIG", "SHONENJUMP_DATA_PATH", "SHONENJUMP_SCORING", "SHONENJUMP_WEIGHT",
+		"SHONENJUMP_AGING_RATE", "SHONENJUMP_MAX_COMPLETE_OPTIONS", "SHONENJUMP_EXCLUDE",
+		"SHONENJUMP_MATCHERS", "SHONENJUMP_RANKING",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "/data")
+	clearEnv(t)
+
+	t.Run("Should use defaults without a config file", func(t *testing.T) {
+		cfg, err := loadConfig("")
+		assert.Nil(t, err)
+		assert.Equal(t, defaultConfig(), cfg)
+		assert.Equal(t, "/data/shonenjump/shonenjump.txt", cfg.DataPath)
+	})
+
+	t.Run("Should read settings from the config file", func(t *testing.T) {
+		path := writeConfig(t, `
+scoring = "frecency"
+max_complete_options = 5
+excludes = ["/tmp", "node_modules"]
+matchers = ["exact", "fuzzy"]
+ranking = "combined"
+
+[match_weights]
+exact = 10
+`)
+		cfg, err := loadConfig(path)
+		assert.Nil(t, err)
+		assert.Equal(t, jump.RankingCombined, cfg.Ranking)
+		assert.Equal(t, float64(10), cfg.MatchWeights["exact"])
+		assert.Equal(t, float64(2), cfg.MatchWeights["fuzzy"], "Missing weights should keep their defaults")
+		assert.Equal(t, jump.ScoringFrecency, cfg.Scoring)
+		assert.Equal(t, 5, cfg.MaxCompleteOptions)
+		assert.Equal(t, []string{"/tmp", "node_modules"}, cfg.Excludes)
+		assert.Equal(t, []string{"exact", "fuzzy"}, cfg.Matchers)
+		assert.Equal(t, jump.DefaultWeight, cfg.Weight, "Missing settings should keep their defaults")
+	})
+
+	t.Run("Should find the config file in XDG_CONFIG_HOME", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, "shonenjump"), 0740))
+		content := []byte(`weight = 30.0`)
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "shonenjump", "config.toml"), content, 0644))
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		cfg, err := loadConfig("")
+		assert.Nil(t, err)
+		assert.Equal(t, float64(30), cfg.Weight)
+	})
+
+	t.Run("Should let environment variables override the config file", func(t *testing.T) {
+		path := writeConfig(t, `
+data_path = "~/jump.txt"
+weight = 30.0
+`)
+		t.Setenv("SHONENJUMP_WEIGHT", "40")
+		t.Setenv("SHONENJUMP_MATCHERS", "consecutive,anywhere")
+		t.Setenv("SHONENJUMP_EXCLUDE", "/tmp:re:^/mnt")
+		t.Setenv("SHONENJUMP_RANKING", "combined")
+		cfg, err := loadConfig(path)
+		assert.Nil(t, err)
+		assert.Equal(t, float64(40), cfg.Weight)
+		assert.Equal(t, []string{"consecutive", "anywhere"}, cfg.Matchers)
+		assert.Equal(t, []string{"/tmp", "re:^/mnt"}, cfg.Excludes)
+		assert.Equal(t, jump.RankingCombined, cfg.Ranking)
+		assert.Equal(t, filepath.Join(homeDir(), "jump.txt"), cfg.DataPath)
+	})
+
+	t.Run("Should reject invalid settings", func(t *testing.T) {
+		invalid := []string{
+			`unknown = 1`,
+			`weight = "heavy"`,
+			`aging_rate = 1.5`,
+			`scoring = "random"`,
+			`matchers = ["exact", "bogus"]`,
+			`excludes = ["re:("]`,
+			`ranking = "random"`,
+			"[match_weights]\nbogus = 1",
+			"[match_weights]\nexact = -1",
+		}
+		for _, content := range invalid {
+			_, err := loadConfig(writeConfig(t, content))
+			assert.NotNil(t, err, content)
+		}
+	})
+
+	t.Run("Should require a config file given explicitly to exist", func(t *testing.T) {
+		_, err := loadConfig("/non/exist/config.toml")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestWriteConfig(t *testing.T) {
+	clearEnv(t)
+	cfg := defaultConfig()
+	cfg.DataPath = "/data/shonenjump.txt"
+	var buf bytes.Buffer
+	assert.Nil(t, cfg.write(&buf))
+
+	loaded, err := loadConfig(writeConfig(t, buf.String()))
+	assert.Nil(t, err)
+	assert.Equal(t, cfg, loaded, "Printed settings should be loadable")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func runDaemon(cfg config, args []string) error {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	interval := flags.Duration("flush-interval", time.Minute, "How often visits are written to the data file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *interval <= 0 {
+		return fmt.Errorf("usage: shonenjump daemon [--flush-interval duration]")
+	}
+	finder, err := cfg.finder()
+	if err != nil {
+		return err
+	}
+	store, err := cfg.newStore(jump.WithFinder(finder))
+	if err != nil {
+		return err
+	}
+	l, err := store.Listen()
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	srv := jump.NewServer(store)
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(l)
+	}()
+	signals := make(chan os.Signal, 1)
+	// SIGHUP is sent when the terminal the daemon was started from is closed
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := srv.Flush(); err != nil {
+				log.Printf("Failed to flush visits: %v", err)
+			}
+		case <-signals:
+			_ = l.Close()
+			return srv.Flush()
+		case err := <-served:
+			if flushErr := srv.Flush(); err == nil {
+				err = flushErr
+			}
+			return err
+		}
+	}
+}
+
+// lookup answers queries from the daemon if it is running, or else from the data file.
+type lookup struct {
+	store  jump.Store
+	finder jump.Finder
+	// useDaemon is false for queries the daemon can't answer,
+	// like the ones limited to a scope around the current directory.
+	useDaemon bool
+}
+
+// dial connects to the daemon, it returns nil if the daemon isn't running.
+func (l lookup) dial() *jump.Client {
+	if !l.useDaemon {
+		return nil
+	}
+	client, err := jump.Dial(l.store.SocketPath())
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+func (l lookup) addPath(path string) error {
+	if client := l.dial(); client != nil {
+		defer client.Close()
+		// Once sent, the visit may have been recorded by the daemon even if the request
+		// failed, like when it times out, so it is only added again if it wasn't sent
+		if err := client.Add(path); !errors.Is(err, jump.ErrNotSent) {
+			return err
+		}
+	}
+	return l.store.AddPath(path)
+}
+
+func (l lookup) bestGuess(args []string) (string, error) {
+	if client := l.dial(); client != nil {
+		defer client.Close()
+		if path, err := client.Query(args); err == nil {
+			return path, nil
+		}
+	}
+	entries, err := l.store.ReadEntries()
+	if err != nil {
+		return "", err
+	}
+	return l.finder.BestGuess(entries, args), nil
+}
+
+func (l lookup) candidates(args []string, limit int) ([]string, error) {
+	if client := l.dial(); client != nil {
+		defer client.Close()
+		if paths, err := client.Candidates(args, limit); err == nil {
+			return paths, nil
+		}
+	}
+	entries, err := l.store.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+	return l.finder.GetCandidates(entries, args, limit), nil
+}
+
+// completions lists the candidates of a keyword being completed,
+// which start with the paths pinned to the keywords it starts.
+func (l lookup) completions(args []string, limit int) ([]string, error) {
+	if client := l.dial(); client != nil {
+		defer client.Close()
+		if paths, err := client.Complete(args, limit); err == nil {
+			return paths, nil
+		}
+	}
+	entries, err := l.store.ReadEntries()
+	if err != nil {
+		return nil, err
+	}
+	return l.finder.Completing().GetCandidates(entries, args, limit), nil
+}
+
+// nthCandidate returns the index-th (1-based) candidate matching args,
+// or defaultPath if there are not enough candidates.
+func (l lookup) nthCandidate(args []string, index int, defaultPath string) (string, error) {
+	candidates, err := l.candidates(args, index)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == index {
+		return candidates[index-1], nil
+	}
+	return defaultPath, nil
+}
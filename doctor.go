@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func runDoctor(cfg config, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "Repair the problems found")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: shonenjump doctor [--fix]")
+	}
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	var d jump.Diagnosis
+	if *fix {
+		d, err = store.Repair()
+	} else {
+		d, err = store.Diagnose()
+	}
+	if err != nil {
+		return err
+	}
+	printDiagnosis(os.Stdout, cfg.DataPath, store.QuarantinePath(), d)
+	if *fix {
+		if d.Problems() > 0 || len(d.Missing) > 0 {
+			fmt.Println("Repaired the data file")
+		}
+		return nil
+	}
+	if d.Problems() > 0 {
+		return fmt.Errorf("found %d problems, run `shonenjump doctor --fix` to repair them", d.Problems())
+	}
+	return nil
+}
+
+func printDiagnosis(w io.Writer, dataPath, quarantinePath string, d jump.Diagnosis) {
+	fmt.Fprintf(w, "Checked %d entries in %s\n", d.Entries, dataPath)
+	for _, line := range d.Invalid {
+		fmt.Fprintf(w, "Invalid line %d: %v\n", line.Number, line.Err)
+	}
+	for _, path := range d.Duplicates {
+		fmt.Fprintf(w, "Duplicate path: %s\n", path)
+	}
+	for _, path := range d.Missing {
+		fmt.Fprintf(w, "Missing path: %s\n", path)
+	}
+	if d.Quarantined > 0 {
+		fmt.Fprintf(w, "%d lines in quarantine, in %s\n", d.Quarantined, quarantinePath)
+	}
+	if d.Problems() == 0 {
+		fmt.Fprintln(w, "No problems found")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+// printExplanations prints a table of the paths found for a query.
+func printExplanations(w io.Writer, explanations []*jump.Explanation) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSCORE\tMATCHER\tDISTANCE\tPATH\tNOTES")
+	for _, ex := range explanations {
+		rank := "-"
+		if ex.Rank != 0 {
+			rank = strconv.Itoa(ex.Rank)
+		}
+		distance := "-"
+		if ex.Distance >= 0 {
+			distance = strconv.Itoa(ex.Distance)
+		}
+		fmt.Fprintf(tw, "%s\t%.2f\t%s\t%s\t%s\t%s\n", rank, ex.Score, ex.Matcher, distance, ex.Path, explanationNotes(ex))
+	}
+	return tw.Flush()
+}
+
+func explanationNotes(ex *jump.Explanation) string {
+	var notes []string
+	if ex.Skipped != "" {
+		notes = append(notes, "skipped: "+ex.Skipped)
+	}
+	if ex.OutrankedBy != "" {
+		notes = append(notes, "outranked by "+ex.OutrankedBy+" match")
+	}
+	if len(ex.Duplicates) > 0 {
+		notes = append(notes, "skipped as duplicate: "+strings.Join(ex.Duplicates, ", "))
+	}
+	return strings.Join(notes, "; ")
+}
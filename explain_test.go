@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func TestPrintExplanations(t *testing.T) {
+	explanations := []*jump.Explanation{
+		{
+			Candidate:  jump.Candidate{Path: "/gone/baz", Score: 20, Matcher: jump.MatchExactName},
+			Skipped:    jump.SkippedInvalidPath,
+			Duplicates: []string{jump.MatchAnywhere},
+		},
+		{
+			Candidate: jump.Candidate{Path: "/foo/baz", Score: 5, Matcher: jump.MatchExactName},
+			Rank:      1,
+		},
+		{
+			Candidate:   jump.Candidate{Path: "/foo/bazar", Score: 30, Matcher: jump.MatchConsecutive},
+			Rank:        2,
+			Distance:    2,
+			OutrankedBy: jump.MatchExactName,
+		},
+		{
+			Candidate: jump.Candidate{Path: "/opt/baz", Matcher: jump.MatchPin},
+			Rank:      3,
+			Distance:  -1,
+		},
+	}
+	var buf bytes.Buffer
+	assert.Nil(t, printExplanations(&buf, explanations))
+	expected := "" +
+		"RANK  SCORE  MATCHER      DISTANCE  PATH        NOTES\n" +
+		"-     20.00  exact        0         /gone/baz   skipped: invalid path; skipped as duplicate: anywhere\n" +
+		"1     5.00   exact        0         /foo/baz    \n" +
+		"2     30.00  consecutive  2         /foo/bazar  outranked by exact match\n" +
+		"3     0.00   pin          -         /opt/baz    \n"
+	assert.Equal(t, expected, buf.String())
+}
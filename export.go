@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func runExport(cfg config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	to := flags.String("to", "", "Format of the database: "+strings.Join(jump.ExportFormats, ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" || flags.NArg() > 1 {
+		return fmt.Errorf("usage: shonenjump export --to %s [file]", strings.Join(jump.ExportFormats, "|"))
+	}
+	// Check the format before creating the file
+	if !contains(jump.ExportFormats, *to) {
+		return fmt.Errorf("unknown format: %q", *to)
+	}
+
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return store.Export(os.Stdout, *to)
+	}
+	f, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := store.Export(f, *to); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func runImport(cfg config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	from := flags.String("from", "", "Format of the database: "+strings.Join(jump.ImportFormats, ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || flags.NArg() != 1 {
+		return fmt.Errorf("usage: shonenjump import --from %s <file>", strings.Join(jump.ImportFormats, "|"))
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	stats, err := store.Import(f, *from)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %d, merged %d, skipped %d\n", stats.Added, stats.Merged, stats.Skipped)
+	return nil
+}
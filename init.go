@@ -0,0 +1,106 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Hooks recording the current directory in the shell integrations
+const (
+	// hookPrompt records the current directory every time the prompt is shown.
+	hookPrompt = "prompt"
+	// hookChpwd records a directory when it is changed to.
+	hookChpwd = "chpwd"
+)
+
+//go:embed shell/*.tmpl
+var shellTemplates embed.FS
+
+// shellInfo describes the integration of a shell.
+type shellInfo struct {
+	// defaultHook is used when no hook is given.
+	defaultHook string
+	// ext is the extension of the script in the scripts directory.
+	ext string
+}
+
+// shells maps the supported shells to their integrations.
+var shells = map[string]shellInfo{
+	"bash":       {hookPrompt, "bash"},
+	"zsh":        {hookChpwd, "zsh"},
+	"fish":       {hookChpwd, "fish"},
+	"nushell":    {hookChpwd, "nu"},
+	"powershell": {hookChpwd, "ps1"},
+	"elvish":     {hookChpwd, "elv"},
+	"xonsh":      {hookChpwd, "xsh"},
+}
+
+func shellNames() []string {
+	names := make([]string, 0, len(shells))
+	for name := range shells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initOptions are the variables of the shell templates.
+type initOptions struct {
+	// Cmd is the name of the command jumping to directories,
+	// the other commands are named after it.
+	Cmd  string
+	Hook string
+}
+
+var validCmd = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// writeShellInit writes the integration of shell, with the default hook if hook is empty.
+func writeShellInit(w io.Writer, shell string, opts initOptions) error {
+	info, ok := shells[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %q", shell)
+	}
+	if !validCmd.MatchString(opts.Cmd) {
+		return fmt.Errorf("invalid command name: %q", opts.Cmd)
+	}
+	switch opts.Hook {
+	case "":
+		opts.Hook = info.defaultHook
+	case hookPrompt, hookChpwd:
+	default:
+		return fmt.Errorf("unknown hook: %q", opts.Hook)
+	}
+	tmpl, err := template.ParseFS(shellTemplates, "shell/"+shell+".tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, opts)
+}
+
+func runInit(cfg config, args []string) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	var opts initOptions
+	flags.StringVar(&opts.Cmd, "cmd", "j", "Name of the command to jump with")
+	flags.StringVar(&opts.Hook, "hook", "", "When to record the current directory: prompt or chpwd")
+	// Allow the flags after the shell too
+	var shell string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		shell, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if shell == "" && flags.NArg() == 1 {
+		shell = flags.Arg(0)
+	} else if shell == "" || flags.NArg() != 0 {
+		return fmt.Errorf("usage: shonenjump init [--cmd name] [--hook prompt|chpwd] <%s>", strings.Join(shellNames(), "|"))
+	}
+	return writeShellInit(os.Stdout, shell, opts)
+}
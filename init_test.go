@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The scripts of the repository are the integrations with the default options,
+// for users who don't use `shonenjump init`.
+func TestScriptsAreInSync(t *testing.T) {
+	for _, shell := range shellNames() {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, writeShellInit(&buf, shell, initOptions{Cmd: "j"}))
+			ext := shells[shell].ext
+			script, err := os.ReadFile(filepath.Join("scripts", "shonenjump."+ext))
+			assert.Nil(t, err)
+			assert.Equal(t, string(script), buf.String(),
+				"Run `go run . init %s > scripts/shonenjump.%s` to update the script", shell, ext)
+		})
+	}
+}
+
+func TestShellInitOptions(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, writeShellInit(&buf, "zsh", initOptions{Cmd: "z", Hook: hookPrompt}))
+	script := buf.String()
+	for _, fn := range []string{"z() {", "zc() {", "zo() {", "zco() {", "compdef _shonenjump z", "precmd_functions+="} {
+		assert.Contains(t, script, fn)
+	}
+	assert.NotContains(t, script, "j() {")
+	assert.NotContains(t, script, "chpwd_functions")
+
+	buf.Reset()
+	assert.Nil(t, writeShellInit(&buf, "fish", initOptions{Cmd: "z", Hook: hookPrompt}))
+	assert.Contains(t, buf.String(), "--on-event fish_prompt")
+	assert.Contains(t, buf.String(), "complete -x -c z ")
+
+	buf.Reset()
+	assert.Nil(t, writeShellInit(&buf, "bash", initOptions{Cmd: "j", Hook: hookChpwd}))
+	assert.Contains(t, buf.String(), `__shonenjump_oldpwd="${PWD}"`)
+
+	assert.NotNil(t, writeShellInit(&buf, "tcsh", initOptions{Cmd: "j"}))
+	assert.NotNil(t, writeShellInit(&buf, "bash", initOptions{Cmd: "j; rm -rf /"}))
+	assert.NotNil(t, writeShellInit(&buf, "bash", initOptions{Cmd: "j", Hook: "precmd"}))
+}
+
+// The generated scripts are checked by the shells that are installed.
+func TestShellInitSyntax(t *testing.T) {
+	checks := map[string][]string{
+		"bash": {"bash", "-n"},
+		"zsh":  {"zsh", "-n"},
+		"fish": {"fish", "--no-execute"},
+		// The xonsh integration sticks to the Python syntax
+		"xonsh": {"python3", "-c", "import ast, sys; ast.parse(sys.stdin.read())"},
+	}
+	for _, shell := range shellNames() {
+		check, ok := checks[shell]
+		if !ok {
+			continue
+		}
+		t.Run(shell, func(t *testing.T) {
+			if _, err := exec.LookPath(check[0]); err != nil {
+				t.Skipf("%s is not installed", check[0])
+			}
+			for _, hook := range []string{hookPrompt, hookChpwd} {
+				var buf bytes.Buffer
+				assert.Nil(t, writeShellInit(&buf, shell, initOptions{Cmd: "z", Hook: hook}))
+				cmd := exec.Command(check[0], check[1:]...)
+				cmd.Stdin = strings.NewReader(buf.String())
+				out, err := cmd.CombinedOutput()
+				assert.Nil(t, err, "%s with %s hook: %s", shell, hook, out)
+			}
+		})
+	}
+}
+
+// stubShonenjump puts a fake shonenjump first in PATH, which logs its arguments
+// to the returned file and answers every query with target.
+func stubShonenjump(t *testing.T, target string) (env []string, log string) {
+	bin := t.TempDir()
+	log = filepath.Join(bin, "args.log")
+	stub := fmt.Sprintf(`#!/bin/sh
+echo "$*" >> '%s'
+case "$1" in
+    --add) ;;
+    --complete) echo "foo__1__%s" ;;
+    *) echo '%s' ;;
+esac
+`, log, target, target)
+	if err := os.WriteFile(filepath.Join(bin, "shonenjump"), []byte(stub), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return env, log
+}
+
+// readStubLog waits for the hooks running shonenjump in the background.
+func readStubLog(t *testing.T, log string, want string) []string {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		data, err := os.ReadFile(log)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		if contains(lines, want) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return lines
+}
+
+// The generated scripts are run against a stub shonenjump by the shells that are installed:
+// changing directory records it and `j` jumps to the best guess.
+func TestShellInitRuns(t *testing.T) {
+	runs := map[string]func(script, start string) []string{
+		"bash": func(script, start string) []string {
+			return []string{"bash", "--norc", "-c", fmt.Sprintf(
+				`source '%s'; cd '%s'; eval "$PROMPT_COMMAND"; j foo; pwd`, script, start)}
+		},
+		"zsh": func(script, start string) []string {
+			return []string{"zsh", "-f", "-c", fmt.Sprintf(
+				`source '%s'; cd '%s'; j foo; pwd`, script, start)}
+		},
+		"fish": func(script, start string) []string {
+			return []string{"fish", "--no-config", "-c", fmt.Sprintf(
+				`source '%s'; cd '%s'; j foo; pwd`, script, start)}
+		},
+		// Hooks only run in the interactive nushell, so it is called directly
+		"nushell": func(script, start string) []string {
+			return []string{"nu", "--no-config-file", "-c", fmt.Sprintf(
+				`source '%s'; do ($env.config.hooks.env_change.PWD | last | get code) null '%s'; j foo; print (pwd)`, script, start)}
+		},
+		"powershell": func(script, start string) []string {
+			return []string{"pwsh", "-NoProfile", "-NonInteractive", "-Command", fmt.Sprintf(
+				`. '%s'; Set-Location '%s'; $null = prompt; j foo; (Get-Location).Path`, script, start)}
+		},
+		"xonsh": func(script, start string) []string {
+			return []string{"xonsh", "--no-rc", "-c", fmt.Sprintf(
+				`source '%s'
+cd '%s'
+j foo
+print(__import__('os').getcwd())`, script, start)}
+		},
+		// The edit module only exists in the interactive elvish, so the script is
+		// evaluated with a stub of it, which collects the functions it defines
+		"elvish": func(script, start string) []string {
+			return []string{"elvish", "-norc", "-c", fmt.Sprintf(
+				`var added = [&]
+var edit: = (ns [&add-var~={|name value| set added[$name] = $value } &before-readline=[] &completion:=(ns [&arg-completer=[&]])])
+eval &ns=(ns [&edit:=$edit:]) (slurp < '%s')
+cd '%s'
+$added['j~'] foo
+echo $pwd`, script, start)}
+		},
+	}
+	for _, shell := range shellNames() {
+		run, ok := runs[shell]
+		if !ok {
+			continue
+		}
+		t.Run(shell, func(t *testing.T) {
+			args := run("", "")
+			if _, err := exec.LookPath(args[0]); err != nil {
+				t.Skipf("%s is not installed", args[0])
+			}
+			start, err := filepath.EvalSymlinks(t.TempDir())
+			assert.Nil(t, err)
+			target, err := filepath.EvalSymlinks(t.TempDir())
+			assert.Nil(t, err)
+			env, log := stubShonenjump(t, target)
+
+			var buf bytes.Buffer
+			assert.Nil(t, writeShellInit(&buf, shell, initOptions{Cmd: "j"}))
+			script := filepath.Join(t.TempDir(), "shonenjump."+shells[shell].ext)
+			assert.Nil(t, os.WriteFile(script, buf.Bytes(), 0o644))
+
+			args = run(script, start)
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Env = env
+			out, err := cmd.CombinedOutput()
+			assert.Nil(t, err, "%s", out)
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			assert.Equal(t, target, lines[len(lines)-1], "%s", out)
+
+			logged := readStubLog(t, log, "--add "+start)
+			assert.Contains(t, logged, "--add "+start)
+			assert.Contains(t, logged, "-- foo")
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+const maxPickerOptions = 20
+
+// errPickerCanceled is returned when the user quits the picker without choosing a path.
+var errPickerCanceled = errors.New("canceled")
+
+type key int
+
+const (
+	keyRune key = iota
+	keyEnter
+	keyCancel
+	keyUp
+	keyDown
+	keyBackspace
+	keyClear
+	keyUnknown
+)
+
+type keyPress struct {
+	key key
+	// r is the typed character of a keyRune
+	r rune
+}
+
+// parseKeys splits the bytes read from the terminal into key presses.
+func parseKeys(b []byte) []keyPress {
+	var presses []keyPress
+	for len(b) > 0 {
+		press, n := parseKey(b)
+		presses = append(presses, press)
+		b = b[n:]
+	}
+	return presses
+}
+
+func parseKey(b []byte) (keyPress, int) {
+	switch b[0] {
+	case '\r', '\n':
+		return keyPress{key: keyEnter}, 1
+	case 3, 4: // ctrl-c, ctrl-d
+		return keyPress{key: keyCancel}, 1
+	case 14: // ctrl-n
+		return keyPress{key: keyDown}, 1
+	case 16: // ctrl-p
+		return keyPress{key: keyUp}, 1
+	case 21: // ctrl-u
+		return keyPress{key: keyClear}, 1
+	case 8, 127:
+		return keyPress{key: keyBackspace}, 1
+	case 27:
+		if len(b) == 1 {
+			return keyPress{key: keyCancel}, 1
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			return keyPress{key: keyUnknown}, 2
+		}
+		// Skip to the final byte of the escape sequence
+		i := 2
+		for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+			i++
+		}
+		if i == len(b) {
+			return keyPress{key: keyUnknown}, i
+		}
+		switch b[i] {
+		case 'A':
+			return keyPress{key: keyUp}, i + 1
+		case 'B':
+			return keyPress{key: keyDown}, i + 1
+		}
+		return keyPress{key: keyUnknown}, i + 1
+	}
+	r, n := utf8.DecodeRune(b)
+	if !unicode.IsPrint(r) {
+		return keyPress{key: keyUnknown}, n
+	}
+	return keyPress{key: keyRune, r: r}, n
+}
+
+// picker holds the state of the interactive picker,
+// apart from the terminal so that it can be tested.
+type picker struct {
+	query      []rune
+	candidates []jump.Candidate
+	selected   int
+	search     func(args []string) []jump.Candidate
+}
+
+func newPicker(query string, search func(args []string) []jump.Candidate) *picker {
+	p := &picker{query: []rune(query), search: search}
+	p.update()
+	return p
+}
+
+func (p *picker) update() {
+	p.candidates = p.search(strings.Fields(string(p.query)))
+	p.selected = 0
+}
+
+// handleKey applies a key press, and tells if the picker is done.
+func (p *picker) handleKey(press keyPress) (done bool, err error) {
+	switch press.key {
+	case keyEnter:
+		if len(p.candidates) == 0 {
+			return false, nil
+		}
+		return true, nil
+	case keyCancel:
+		return true, errPickerCanceled
+	case keyUp:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case keyDown:
+		if p.selected < len(p.candidates)-1 {
+			p.selected++
+		}
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.update()
+		}
+	case keyClear:
+		p.query = nil
+		p.update()
+	case keyRune:
+		p.query = append(p.query, press.r)
+		p.update()
+	}
+	return false, nil
+}
+
+func (p *picker) choice() string {
+	return p.candidates[p.selected].Path
+}
+
+// render draws the picker below the cursor and moves the cursor back to the query.
+func (p *picker) render(w io.Writer, width int) {
+	var sb strings.Builder
+	sb.WriteString("\r\x1b[J> ")
+	sb.WriteString(string(p.query))
+	for i, c := range p.candidates {
+		line := []rune(fmt.Sprintf("%8.2f  %s", c.Score, c.Path))
+		if len(line) > width-2 && width > 2 {
+			line = line[:width-2]
+		}
+		if i == p.selected {
+			sb.WriteString("\r\n\x1b[7m> " + string(line) + "\x1b[0m")
+		} else {
+			sb.WriteString("\r\n  " + string(line))
+		}
+	}
+	if len(p.candidates) > 0 {
+		fmt.Fprintf(&sb, "\x1b[%dA", len(p.candidates))
+	}
+	fmt.Fprintf(&sb, "\r\x1b[%dC", 2+len(p.query))
+	fmt.Fprint(w, sb.String())
+}
+
+// runPicker lets the user choose a path matching query on the terminal.
+func runPicker(store jump.Store, finder jump.Finder, query string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return "", err
+	}
+	// Leave a line for the query
+	limit := maxPickerOptions
+	if height-1 < limit && height > 1 {
+		limit = height - 1
+	}
+
+	entries, err := store.ReadEntries()
+	if err != nil {
+		return "", err
+	}
+	p := newPicker(query, func(args []string) []jump.Candidate {
+		if len(args) == 0 {
+			return topCandidates(entries, limit)
+		}
+		return finder.Find(entries, args, limit)
+	})
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		fmt.Fprint(tty, "\r\x1b[J")
+		_ = term.Restore(fd, state)
+	}()
+
+	buf := make([]byte, 64)
+	for {
+		p.render(tty, width)
+		n, err := tty.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		for _, press := range parseKeys(buf[:n]) {
+			done, err := p.handleKey(press)
+			if err != nil {
+				return "", err
+			}
+			if done {
+				return p.choice(), nil
+			}
+		}
+	}
+}
+
+// topCandidates lists the highest ranked existing paths, for an empty query.
+func topCandidates(entries jump.EntryList, limit int) []jump.Candidate {
+	var candidates []jump.Candidate
+	for _, e := range entries {
+		if len(candidates) >= limit {
+			break
+		}
+		if _, err := os.Stat(e.Path); err != nil {
+			continue
+		}
+		candidates = append(candidates, jump.Candidate{Path: e.Path, Score: e.Score})
+	}
+	return candidates
+}
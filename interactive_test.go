@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+func TestParseKeys(t *testing.T) {
+	presses := parseKeys([]byte("a世\x1b[A\x1bOB\x0e\x10\x7f\x15\x1b[3~\r\x03"))
+	expected := []keyPress{
+		{key: keyRune, r: 'a'},
+		{key: keyRune, r: '世'},
+		{key: keyUp},
+		{key: keyDown},
+		{key: keyDown},
+		{key: keyUp},
+		{key: keyBackspace},
+		{key: keyClear},
+		{key: keyUnknown},
+		{key: keyEnter},
+		{key: keyCancel},
+	}
+	assert.Equal(t, expected, presses)
+	assert.Equal(t, []keyPress{{key: keyCancel}}, parseKeys([]byte("\x1b")))
+}
+
+func TestPicker(t *testing.T) {
+	paths := []string{"/src/shonenjump", "/src/autojump", "/music"}
+	search := func(args []string) []jump.Candidate {
+		var result []jump.Candidate
+		for _, p := range paths {
+			if strings.Contains(p, strings.Join(args, "")) {
+				result = append(result, jump.Candidate{Path: p, Score: 10})
+			}
+		}
+		return result
+	}
+	press := func(p *picker, keys string) (done bool, err error) {
+		for _, k := range parseKeys([]byte(keys)) {
+			if done, err = p.handleKey(k); done {
+				return
+			}
+		}
+		return
+	}
+
+	t.Run("Should filter candidates as the query changes", func(t *testing.T) {
+		p := newPicker("", search)
+		assert.Len(t, p.candidates, 3)
+		_, err := press(p, "jump")
+		assert.Nil(t, err)
+		assert.Len(t, p.candidates, 2)
+		_, err = press(p, "\x7f\x7f\x7f\x7f")
+		assert.Nil(t, err)
+		assert.Len(t, p.candidates, 3)
+	})
+
+	t.Run("Should choose the selected candidate", func(t *testing.T) {
+		p := newPicker("jump", search)
+		done, err := press(p, "\x1b[B\x1b[B\x10\x0e\r")
+		assert.Nil(t, err)
+		assert.True(t, done)
+		assert.Equal(t, "/src/autojump", p.choice())
+	})
+
+	t.Run("Should reset the selection when the query changes", func(t *testing.T) {
+		p := newPicker("", search)
+		_, err := press(p, "\x0e\x0e")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, p.selected)
+		_, err = press(p, "s")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, p.selected)
+	})
+
+	t.Run("Should not finish without candidates", func(t *testing.T) {
+		p := newPicker("nothing", search)
+		done, err := press(p, "\r")
+		assert.Nil(t, err)
+		assert.False(t, done)
+	})
+
+	t.Run("Should be canceled", func(t *testing.T) {
+		p := newPicker("", search)
+		done, err := press(p, "\x1b")
+		assert.True(t, done)
+		assert.Equal(t, errPickerCanceled, err)
+	})
+
+	t.Run("Should render query and candidates", func(t *testing.T) {
+		p := newPicker("jump", search)
+		var buf bytes.Buffer
+		p.render(&buf, 80)
+		output := buf.String()
+		assert.Contains(t, output, "> jump")
+		assert.Contains(t, output, "\x1b[7m>    10.00  /src/shonenjump\x1b[0m")
+		assert.Contains(t, output, "     10.00  /src/autojump")
+	})
+}
@@ -0,0 +1,378 @@
+package jump
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Requests of the daemon protocol.
+//
+// A request is a line of tab separated fields, its name followed by its arguments.
+// The response starts with a line of "ok", or of "error" and a message separated by a tab,
+// followed by the lines of the results and an empty line.
+const (
+	// requestAdd records a visit to an absolute path.
+	requestAdd = "add"
+	// requestQuery finds the best guess of its arguments.
+	requestQuery = "query"
+	// requestCandidates finds at most as many candidates as its first argument.
+	requestCandidates = "candidates"
+	// requestComplete works like requestCandidates for a keyword being completed.
+	requestComplete = "complete"
+
+	responseOK    = "ok"
+	responseError = "error"
+)
+
+const (
+	dialTimeout    = 100 * time.Millisecond
+	requestTimeout = 2 * time.Second
+)
+
+// ErrNotSent is returned by the requests of a Client that didn't reach the daemon.
+// Other errors may happen after the daemon has handled the request, so requests
+// shouldn't be repeated without the daemon then.
+var ErrNotSent = errors.New("request not sent to the daemon")
+
+// SocketPath is where the daemon serving the Store listens.
+func (s Store) SocketPath() string {
+	return s.path + ".sock"
+}
+
+// Listen creates the socket of the daemon serving the Store.
+// The socket of a daemon that is no longer running is replaced.
+func (s Store) Listen() (net.Listener, error) {
+	path := s.SocketPath()
+	if client, err := Dial(path); err == nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("daemon already running on %v", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// visit is a visit recorded by the daemon but not written to the data file yet.
+type visit struct {
+	path string
+	t    time.Time
+}
+
+// Server keeps the entries of a Store in memory and answers the requests of
+// Clients, so that adding paths and querying them doesn't read and rewrite the
+// data file every time.
+// Visits are written to the data file by Flush. Until then they are kept on top
+// of the data file, which is read again when other processes change it.
+type Server struct {
+	store Store
+
+	mu      sync.Mutex
+	entries EntryList
+	pending []visit
+	// info is the data file entries were read from, nil if it doesn't exist
+	info os.FileInfo
+	// journalInfo is the journal of visits added without the daemon, nil if it doesn't exist
+	journalInfo os.FileInfo
+	// loaded is false until entries are read
+	loaded bool
+}
+
+// NewServer creates a Server for the entries of s.
+func NewServer(s Store) *Server {
+	return &Server{store: s}
+}
+
+// Serve answers the requests of the connections to l, until l is closed.
+func (srv *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	writer := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		results, err := srv.serve(strings.Split(scanner.Text(), "\t"))
+		if err != nil {
+			fmt.Fprintf(writer, "%s\t%s\n", responseError, strings.ReplaceAll(err.Error(), "\n", " "))
+		} else {
+			fmt.Fprintln(writer, responseOK)
+			for _, r := range results {
+				fmt.Fprintln(writer, r)
+			}
+		}
+		fmt.Fprintln(writer)
+		if writer.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (srv *Server) serve(fields []string) ([]string, error) {
+	name, args := fields[0], fields[1:]
+	switch name {
+	case requestAdd:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s takes a path", name)
+		}
+		return nil, srv.add(args[0])
+	case requestQuery:
+		paths, err := srv.find(args, 1, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(paths) == 0 {
+			return []string{"."}, nil
+		}
+		return paths, nil
+	case requestCandidates, requestComplete:
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s takes a limit", name)
+		}
+		limit, err := strconv.Atoi(args[0])
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit: %q", args[0])
+		}
+		return srv.find(args[1:], limit, name == requestComplete)
+	}
+	return nil, fmt.Errorf("unknown request: %q", name)
+}
+
+func (srv *Server) add(pathToAdd string) error {
+	if !filepath.IsAbs(pathToAdd) {
+		return fmt.Errorf("path is not absolute: %v", pathToAdd)
+	}
+	path, ok, err := srv.store.pathToAdd(pathToAdd)
+	if err != nil || !ok {
+		return err
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := srv.refresh(); err != nil {
+		return err
+	}
+	t := now()
+	srv.entries = srv.store.visit(srv.entries, path, t)
+	srv.pending = append(srv.pending, visit{path, t})
+	return nil
+}
+
+func (srv *Server) find(args []string, limit int, completing bool) ([]string, error) {
+	pins, err := srv.store.ReadPins()
+	if err != nil {
+		return nil, err
+	}
+	finder := srv.store.finder.WithPins(pins)
+	if completing {
+		finder = finder.Completing()
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if err := srv.refresh(); err != nil {
+		return nil, err
+	}
+	return finder.GetCandidates(srv.entries, args, limit), nil
+}
+
+// refresh reads the data file again if another process changed it,
+// and applies the pending visits to it.
+func (srv *Server) refresh() error {
+	info, err := statIfExists(srv.store.path)
+	if err != nil {
+		return err
+	}
+	journalInfo, err := statIfExists(srv.store.journalPath())
+	if err != nil {
+		return err
+	}
+	if srv.loaded && sameFile(srv.info, info) && sameFile(srv.journalInfo, journalInfo) {
+		return nil
+	}
+	entries, err := srv.store.ReadEntries()
+	if err != nil {
+		return err
+	}
+	for _, v := range srv.pending {
+		entries = srv.store.visit(entries, v.path, v.t)
+	}
+	srv.entries, srv.loaded = entries, true
+	// Reading the entries folds the journal into the data file
+	if journalInfo != nil {
+		if info, err = statIfExists(srv.store.path); err != nil {
+			return err
+		}
+		journalInfo = nil
+	}
+	srv.info, srv.journalInfo = info, journalInfo
+	return nil
+}
+
+// statIfExists returns nil if there is no file at path.
+func statIfExists(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return info, err
+}
+
+// Flush writes the pending visits to the data file.
+func (srv *Server) Flush() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.pending) == 0 {
+		return nil
+	}
+	return srv.store.withLock(func() error {
+		entries, rejected, err := srv.store.readEntries()
+		if err != nil {
+			return err
+		}
+		for _, v := range srv.pending {
+			entries = srv.store.visit(entries, v.path, v.t)
+		}
+		if err := srv.store.saveEntries(entries, rejected); err != nil {
+			return err
+		}
+		info, err := os.Stat(srv.store.path)
+		if err != nil {
+			return err
+		}
+		srv.entries, srv.info, srv.journalInfo, srv.loaded = entries, info, nil, true
+		srv.pending = nil
+		return nil
+	})
+}
+
+// sameFile tells if a and b are the same unchanged file, or both missing.
+func sameFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// Client sends requests to the daemon.
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Dial connects to the daemon listening on socketPath.
+func Dial(socketPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the connection to the daemon.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Add records a visit to pathToAdd, like Store.AddPath.
+func (c *Client) Add(pathToAdd string) error {
+	path, err := preprocessPath(pathToAdd)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(requestAdd, path)
+	return err
+}
+
+// Query returns the best guess of args, like Finder.BestGuess.
+func (c *Client) Query(args []string) (string, error) {
+	results, err := c.do(requestQuery, args...)
+	if err != nil {
+		return "", err
+	}
+	if len(results) != 1 {
+		return "", fmt.Errorf("unexpected response: %q", results)
+	}
+	return results[0], nil
+}
+
+// Candidates returns at most limit candidates of args, like Finder.GetCandidates.
+func (c *Client) Candidates(args []string, limit int) ([]string, error) {
+	return c.do(requestCandidates, append([]string{strconv.Itoa(limit)}, args...)...)
+}
+
+// Complete returns at most limit candidates of a keyword being completed,
+// like the Finder returned by Finder.Completing.
+func (c *Client) Complete(args []string, limit int) ([]string, error) {
+	return c.do(requestComplete, append([]string{strconv.Itoa(limit)}, args...)...)
+}
+
+func (c *Client) do(name string, args ...string) ([]string, error) {
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "\t\n") {
+			return nil, fmt.Errorf("%w: invalid argument: %q", ErrNotSent, arg)
+		}
+	}
+	if err := c.conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSent, err)
+	}
+	request := strings.Join(append([]string{name}, args...), "\t")
+	if _, err := fmt.Fprintln(c.conn, request); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotSent, err)
+	}
+
+	status, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		results = append(results, line)
+	}
+	if msg := strings.TrimPrefix(status, responseError+"\t"); msg != status {
+		return nil, errors.New(msg)
+	}
+	if status != responseOK {
+		return nil, fmt.Errorf("unexpected response: %q", status)
+	}
+	return results, nil
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
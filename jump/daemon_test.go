@@ -0,0 +1,162 @@
+package jump
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, store Store) (*Server, *Client) {
+	l, err := store.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	srv := NewServer(store)
+	go func() {
+		assert.Nil(t, srv.Serve(l))
+	}()
+	client, err := Dial(store.SocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return srv, client
+}
+
+func TestDaemon(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	alpha := filepath.Join(dir, "proj-alpha")
+	beta := filepath.Join(dir, "proj-beta")
+	for _, p := range []string{alpha, beta} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	srv, client := startServer(t, store)
+	for _, p := range []string{alpha, alpha, beta} {
+		assert.Nil(t, client.Add(p))
+	}
+	_, err = os.Stat(filepath.Join(dir, "testEntries"))
+	assert.True(t, os.IsNotExist(err), "Visits are only written by Flush")
+
+	guess, err := client.Query([]string{"proj"})
+	assert.Nil(t, err)
+	assert.Equal(t, alpha, guess)
+	candidates, err := client.Complete([]string{"proj"}, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{alpha, beta}, candidates)
+	candidates, err = client.Candidates([]string{"proj"}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{alpha}, candidates)
+	guess, err = client.Query([]string{"nothing"})
+	assert.Nil(t, err)
+	assert.Equal(t, ".", guess)
+
+	// The same scores as adding the paths to the data file directly
+	assert.Nil(t, srv.Flush())
+	expected := NewStore(filepath.Join(dir, "expectedEntries"))
+	for _, p := range []string{alpha, alpha, beta} {
+		assert.Nil(t, expected.AddPath(p))
+	}
+	expectedEntries, err := expected.ReadEntries()
+	assert.Nil(t, err)
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	for i, e := range entries {
+		assert.Equal(t, expectedEntries[i].Path, e.Path)
+		assert.Equal(t, expectedEntries[i].Score, e.Score)
+		assert.Equal(t, expectedEntries[i].Visits, e.Visits)
+	}
+
+	// Changes of other processes are seen, and kept by the next Flush
+	assert.Nil(t, client.Add(alpha))
+	_, err = store.Adjust(beta, 1000)
+	assert.Nil(t, err)
+	guess, err = client.Query([]string{"proj"})
+	assert.Nil(t, err)
+	assert.Equal(t, beta, guess)
+	assert.Nil(t, srv.Flush())
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, beta, entries[0].Path)
+	assert.Equal(t, 3, entries.find(alpha).Visits)
+}
+
+func TestDaemonErrors(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	_, client := startServer(t, store)
+	assert.NotNil(t, client.Add(filepath.Join(dir, "missing")))
+	_, err = client.Complete([]string{"proj"}, 0)
+	assert.NotNil(t, err)
+	_, err = client.Query([]string{"a\tb"})
+	assert.True(t, errors.Is(err, ErrNotSent), "Expected the request not to be sent, got %v", err)
+	_, err = client.do("unknown")
+	assert.NotNil(t, err)
+	// The connection is still usable after errors
+	assert.Nil(t, client.Add(dir))
+
+	_, err = store.Listen()
+	assert.NotNil(t, err, "Only one daemon can serve a data file")
+}
+
+func TestClientErrorsAfterSending(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A daemon that goes away after reading the request
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	l, err := store.Listen()
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		_ = conn.Close()
+	}()
+
+	client, err := Dial(store.SocketPath())
+	assert.Nil(t, err)
+	defer client.Close()
+	err = client.Add(dir)
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrNotSent), "The request was sent")
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, os.WriteFile(store.SocketPath(), nil, 0600))
+	l, err := store.Listen()
+	assert.Nil(t, err)
+	assert.Nil(t, l.Close())
+
+	_, err = Dial(store.SocketPath())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,30 @@
+/*
+Package jump implements the database of visited directories behind shonenjump
+and the matching rules used to jump to them.
+
+A Store records visits in a data file and reads them back as an EntryList:
+
+	store := jump.NewStore(dataPath)
+	if err := store.AddPath(dir); err != nil {
+		return err
+	}
+	entries, err := store.ReadEntries()
+	if err != nil {
+		return err
+	}
+	fmt.Println(jump.BestGuess(entries, []string{"proj"}))
+
+How scores change on every visit, and how entries are ranked, is decided by
+a Scorer and can be customized with WithScorer. A Scorer may also implement
+Combiner, to combine the scores of entries of the same path, and VisitConverter,
+to convert its scores when the databases of other jumpers are imported or exported.
+
+# Compatibility
+
+The exported API of this package follows the versioning of the module:
+it only changes in backward incompatible ways when the major version changes.
+Data files of autojump can be read as they are, and new information is only
+ever added to the data file in extra columns, so that older versions can still
+read it.
+*/
+package jump
@@ -0,0 +1,165 @@
+package jump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// QuarantinePath is where the lines of the data file that can't be parsed are
+// moved when the data file is saved, so that they can be fixed by hand
+// instead of being lost.
+func (s Store) QuarantinePath() string {
+	return s.path + ".quarantine"
+}
+
+// quarantine appends lines of the data file that can't be parsed to the
+// quarantine file. It has to be called with the lock held.
+func (s Store) quarantine(lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	file, err := os.OpenFile(s.QuarantinePath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(writer, line); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// InvalidLine is a line of the data file that can't be parsed.
+type InvalidLine struct {
+	// Number is the 1-based number of the line.
+	Number int
+	Text   string
+	Err    error
+}
+
+// Diagnosis lists the problems found in the data file.
+type Diagnosis struct {
+	// Entries counts the lines that can be parsed.
+	Entries int
+	Invalid []InvalidLine
+	// Duplicates are the paths recorded on several lines.
+	Duplicates []string
+	// Missing are the paths that no longer exist. They are expected as
+	// directories get deleted, and aren't counted as problems.
+	Missing []string
+	// Quarantined counts the lines already in the quarantine file.
+	Quarantined int
+}
+
+// Problems counts the lines that can't be parsed and the duplicated paths.
+func (d Diagnosis) Problems() int {
+	return len(d.Invalid) + len(d.Duplicates)
+}
+
+// Diagnose checks the data file for problems.
+func (s Store) Diagnose() (Diagnosis, error) {
+	var d Diagnosis
+	seen := make(map[string]int)
+	err := s.scanDataFile(func(n int, line string, ent Entry, err error) bool {
+		if err != nil {
+			d.Invalid = append(d.Invalid, InvalidLine{n, line, err})
+			return true
+		}
+		d.Entries++
+		seen[ent.Path]++
+		switch {
+		case seen[ent.Path] == 2:
+			d.Duplicates = append(d.Duplicates, ent.Path)
+		case seen[ent.Path] == 1 && !isValidPath(ent.Path):
+			d.Missing = append(d.Missing, ent.Path)
+		}
+		return true
+	})
+	if err != nil {
+		return d, err
+	}
+	d.Quarantined, err = countLines(s.QuarantinePath())
+	return d, err
+}
+
+// Repair fixes the problems of the data file and returns the ones it found:
+// lines that can't be parsed are moved to the quarantine file, the entries of
+// the same path are combined, and the entries of missing paths are removed.
+func (s Store) Repair() (Diagnosis, error) {
+	var d Diagnosis
+	err := s.withLock(func() error {
+		var err error
+		if d, err = s.Diagnose(); err != nil {
+			return err
+		}
+		if d.Problems() == 0 && len(d.Missing) == 0 {
+			return nil
+		}
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		t := now()
+		entries = combineDuplicates(entries, s.scorer, t)
+		entries.sortByRank(s.scorer, t)
+		if err := s.saveEntries(entries, rejected); err != nil {
+			return err
+		}
+		d.Quarantined += len(d.Invalid)
+		return nil
+	})
+	return d, err
+}
+
+// combineDuplicates merges the entries of the same path into the first one,
+// combining their scores with scorer, and sums their visits.
+func combineDuplicates(entries EntryList, scorer Scorer, t time.Time) EntryList {
+	var combined EntryList
+	byPath := make(map[string]*Entry, len(entries))
+	for _, e := range entries {
+		if ent, ok := byPath[e.Path]; ok {
+			combine(scorer, ent, e.Score, t)
+			mergeMetadata(ent, e)
+			continue
+		}
+		byPath[e.Path] = e
+		combined = append(combined, e)
+	}
+	return combined
+}
+
+func countLines(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var n int
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			n++
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package jump
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnoseAndRepair(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+	missing := filepath.Join(dir, "missing")
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	content := fmt.Sprintf("30\t%s\r\n\n10\nNaN\t%s\n20\t%s\t2020-05-01T10:00:00Z\t2\t-\n5\t%s\n40\t%s\t2020-04-01T10:00:00Z\t3\t-\n",
+		a, b, b, missing, b)
+	assert.Nil(t, os.WriteFile(store.path, []byte(content), 0640))
+
+	d, err := store.Diagnose()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, d.Entries)
+	assert.Len(t, d.Invalid, 2)
+	assert.Equal(t, 3, d.Invalid[0].Number)
+	assert.Equal(t, "10", d.Invalid[0].Text)
+	assert.Equal(t, "NaN\t"+b, d.Invalid[1].Text)
+	assert.Equal(t, []string{b}, d.Duplicates)
+	assert.Equal(t, []string{missing}, d.Missing)
+	assert.Equal(t, 0, d.Quarantined)
+	assert.Equal(t, 3, d.Problems())
+
+	repaired, err := store.Repair()
+	assert.Nil(t, err)
+	assert.Equal(t, d.Invalid, repaired.Invalid)
+	assert.Equal(t, 2, repaired.Quarantined)
+
+	quarantined, err := os.ReadFile(store.QuarantinePath())
+	assert.Nil(t, err)
+	assert.Equal(t, "10\nNaN\t"+b+"\n", string(quarantined))
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, b, entries[0].Path)
+	assert.InDelta(t, 44.72, entries[0].Score, 0.01)
+	assert.Equal(t, 5, entries[0].Visits)
+
+	d, err = store.Diagnose()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, d.Problems())
+	assert.Empty(t, d.Missing)
+	assert.Equal(t, 2, d.Quarantined)
+}
+
+func TestRepairWithFrecency(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"), WithScorer(FrecencyScorer{}))
+	content := fmt.Sprintf("3\t%s\n4\t%s\n", dir, dir)
+	assert.Nil(t, os.WriteFile(store.path, []byte(content), 0640))
+
+	_, err = store.Repair()
+	assert.Nil(t, err)
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 7.0, entries[0].Score, "Visits should be summed")
+}
+
+func TestSavingQuarantinesInvalidLines(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, os.WriteFile(store.path, []byte("10\n-5\t/tmp\n"), 0640))
+	_, err = store.Adjust(dir, 10)
+	assert.Nil(t, err)
+	_, err = store.Adjust(dir, 10)
+	assert.Nil(t, err)
+
+	quarantined, err := os.ReadFile(store.QuarantinePath())
+	assert.Nil(t, err)
+	assert.Equal(t, "10\n-5\t/tmp\n", string(quarantined), "Lines are only quarantined once")
+}
@@ -9,18 +9,22 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	defaultWeight = 20.0
+	// DefaultWeight is the weight of a regular visit to a path.
+	DefaultWeight = 20.0
+	// DefaultAgingRate is the part of their scores entries lose when another path is visited.
+	DefaultAgingRate = 0.1
 )
 
 func clearNotExistDirs(entries EntryList) (result EntryList, changed bool) {
 	for _, e := range entries {
-		if isValidPath(e.val) {
+		if isValidPath(e.Path) {
 			result = append(result, e)
 		} else {
-			log.Printf("Directory %s no longer exists", e.val)
+			log.Printf("Directory %s no longer exists", e.Path)
 			changed = true
 		}
 	}
@@ -28,40 +32,96 @@ func clearNotExistDirs(entries EntryList) (result EntryList, changed bool) {
 }
 
 // Entry correspond to a line in the data file
-type entry struct {
-	val   string
-	score float64
+type Entry struct {
+	// Path is the absolute path of a visited directory.
+	Path string
+	// Score measures how often the path is visited, higher is better.
+	// How it changes over time is up to the Scorer of the Store.
+	Score float64
+
+	// Optional metadata, absent from autojump-style data files
+	LastVisit time.Time
+	Visits    int
+	FirstSeen time.Time
+}
+
+// NewEntry creates an entry without metadata, like the ones in autojump's data file.
+func NewEntry(path string, score float64) *Entry {
+	return &Entry{Path: path, Score: score}
 }
 
-func (e *entry) updateScore(weight float64) float64 {
-	e.score = math.Sqrt(math.Pow(e.score, 2) + math.Pow(weight, 2))
-	return e.score
+func (e *Entry) updateScore(weight float64) float64 {
+	if weight < 0 {
+		e.Score = math.Max(e.Score+weight, 0)
+		return e.Score
+	}
+	e.Score = math.Sqrt(math.Pow(e.Score, 2) + math.Pow(weight, 2))
+	return e.Score
 }
 
-func (e entry) String() string {
-	return fmt.Sprintf("%.2f\t%s", e.score, e.val)
+func (e *Entry) recordVisit(t time.Time) {
+	if e.FirstSeen.IsZero() {
+		e.FirstSeen = t
+	}
+	e.LastVisit = t
+	e.Visits++
 }
 
-type EntryList []*entry
+func (e Entry) hasMetadata() bool {
+	return e.Visits != 0 || !e.LastVisit.IsZero() || !e.FirstSeen.IsZero()
+}
 
+// String formats the entry as a line of the data file.
+func (e Entry) String() string {
+	if !e.hasMetadata() {
+		return fmt.Sprintf("%.2f\t%s", e.Score, e.Path)
+	}
+	return fmt.Sprintf(
+		"%.2f\t%s\t%s\t%d\t%s",
+		e.Score, e.Path, formatTime(e.LastVisit), e.Visits, formatTime(e.FirstSeen),
+	)
+}
+
+// EntryList is a list of entries, usually sorted by rank.
+type EntryList []*Entry
+
+// Sort sorts the entries by score in descending order.
 func (entries EntryList) Sort() {
 	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].score > entries[j].score
+		return entries[i].Score > entries[j].Score
 	})
 }
 
-func (entries EntryList) Update(val string, weight float64) EntryList {
-	var ent *entry
+func (entries EntryList) sortByRank(sc Scorer, t time.Time) {
+	sort.Slice(entries, func(i, j int) bool {
+		return sc.Rank(entries[i], t) > sc.Rank(entries[j], t)
+	})
+}
+
+func (entries EntryList) find(val string) *Entry {
 	for _, e := range entries {
-		if e.val == val {
-			ent = e
-			break
+		if e.Path == val {
+			return e
 		}
 	}
+	return nil
+}
+
+// add makes sure val is in entries and returns its entry.
+func (entries EntryList) add(val string) (EntryList, *Entry) {
+	ent := entries.find(val)
 	if ent == nil {
-		ent = &entry{val, 0}
+		ent = &Entry{Path: val}
 		entries = append(entries, ent)
 	}
+	return entries, ent
+}
+
+// Update adds weight to the score of the entry for val using the classic rule,
+// adding the entry if necessary. A negative weight is subtracted from the score.
+// The returned list is sorted by score.
+func (entries EntryList) Update(val string, weight float64) EntryList {
+	entries, ent := entries.add(val)
 	ent.updateScore(weight)
 
 	entries.Sort()
@@ -69,26 +129,122 @@ func (entries EntryList) Update(val string, weight float64) EntryList {
 	return entries
 }
 
-// As entries get older, their scores become lower.
+// remove drops the entries of paths, returning the remaining and the removed entries.
+func (entries EntryList) remove(paths []string) (kept EntryList, removed EntryList) {
+	toRemove := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		toRemove[p] = true
+	}
+	for _, e := range entries {
+		if toRemove[e.Path] {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	return kept, removed
+}
+
+// Age lowers the scores of all entries by 10%.
 func (entries EntryList) Age() {
+	entries.ageBy(DefaultAgingRate)
+}
+
+func (entries EntryList) ageBy(rate float64) {
+	// Divide rather than multiply, so that the default rate doesn't
+	// push exact results like 30 * 0.1 past the next integer.
+	divisor := 1 / rate
 	for _, e := range entries {
-		delta := math.Ceil(e.score / 10)
-		e.score = math.Max(e.score-delta, 0)
+		delta := math.Ceil(e.Score / divisor)
+		e.Score = math.Max(e.Score-delta, 0)
 	}
 }
 
-func parseEntry(s string) (ent entry, err error) {
+// ParseEntry parses a line of the data file.
+// Lines in the format of autojump, with only a score and a path, are accepted too.
+// A trailing carriage return, left by editors on Windows, is ignored.
+func ParseEntry(s string) (ent Entry, err error) {
+	s = strings.TrimRight(s, "\r")
 	parts := strings.Split(s, "\t")
-	score, err := strconv.ParseFloat(parts[0], 64)
+	if len(parts) < 2 {
+		return ent, fmt.Errorf("missing path: %q", s)
+	}
+	score, err := parseScore(parts[0])
 	if err != nil {
 		return
 	}
-	ent = entry{parts[1], score}
+	if len(parts) < 5 {
+		ent = Entry{Path: strings.Join(parts[1:], "\t"), Score: score}
+		return ent, validateEntryPath(ent.Path)
+	}
+	// Metadata columns are taken from the end, paths may contain tabs
+	n := len(parts)
+	ent = Entry{Path: strings.Join(parts[1:n-3], "\t"), Score: score}
+	if err = validateEntryPath(ent.Path); err != nil {
+		return
+	}
+	if ent.LastVisit, err = parseTime(parts[n-3]); err != nil {
+		return
+	}
+	if ent.Visits, err = strconv.Atoi(parts[n-2]); err != nil {
+		return
+	}
+	if ent.Visits < 0 {
+		return ent, fmt.Errorf("negative visits: %v", ent.Visits)
+	}
+	if ent.FirstSeen, err = parseTime(parts[n-1]); err != nil {
+		return
+	}
 	return ent, nil
 }
 
+// parseScore accepts the finite and positive scores the Scorers produce.
+func parseScore(s string) (float64, error) {
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return 0, fmt.Errorf("score is not finite: %q", s)
+	}
+	if score < 0 {
+		return 0, fmt.Errorf("negative score: %q", s)
+	}
+	// Turn -0 into 0
+	return math.Abs(score), nil
+}
+
+func validateEntryPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("missing path")
+	}
+	if strings.ContainsAny(path, "\n\x00") {
+		return fmt.Errorf("invalid path: %q", path)
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path is not absolute: %q", path)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "-" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 func preprocessPath(path string) (string, error) {
 	// normalize the input
-	path = strings.TrimSuffix(path, string(os.PathSeparator))
+	if path != string(os.PathSeparator) {
+		path = strings.TrimSuffix(path, string(os.PathSeparator))
+	}
 	return filepath.Abs(path)
 }
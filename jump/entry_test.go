@@ -1,35 +1,38 @@
 package jump
 
 import (
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestEntryListSort(t *testing.T) {
-	rawEntries := []*entry{
-		{"b", 10},
-		{"a", 20},
-		{"c", 15},
+	rawEntries := []*Entry{
+		{Path: "b", Score: 10},
+		{Path: "a", Score: 20},
+		{Path: "c", Score: 15},
 	}
 	entries := EntryList(rawEntries)
 	entries.Sort()
 	expected := []string{"a", "c", "b"}
 	for i, e := range entries {
-		assert.Equal(t, expected[i], e.val)
+		assert.Equal(t, expected[i], e.Path)
 	}
 }
 
 func TestEntryListUpdate(t *testing.T) {
 	entries := EntryList{
-		&entry{"/path_b", 10},
-		&entry{"/path_a", 0},
+		&Entry{Path: "/path_b", Score: 10},
+		&Entry{Path: "/path_a", Score: 0},
 	}
 	entries = entries.Update("/path_a", 1)
-	assert.Equal(t, float64(10), entries[0].score)
-	assert.Equal(t, float64(1), entries[1].score)
+	assert.Equal(t, float64(10), entries[0].Score)
+	assert.Equal(t, float64(1), entries[1].Score)
 
 	entries = entries.Update("/path_c", 1)
 	assert.Len(t, entries, 3)
@@ -37,29 +40,156 @@ func TestEntryListUpdate(t *testing.T) {
 
 func TestEntryListAge(t *testing.T) {
 	entries := EntryList{
-		&entry{"a", 20},
-		&entry{"b", 10},
-		&entry{"c", 0},
+		&Entry{Path: "a", Score: 20},
+		&Entry{Path: "b", Score: 10},
+		&Entry{Path: "c", Score: 0},
 	}
 	entries.Age()
 	expected := []float64{18.0, 9.0, 0}
 	for i, e := range entries {
-		assert.Equal(t, expected[i], e.score)
+		assert.Equal(t, expected[i], e.Score)
 	}
+
+	entries = EntryList{{Path: "a", Score: 30}}
+	entries.Age()
+	assert.Equal(t, float64(27), entries[0].Score)
 }
 
 func TestString(t *testing.T) {
-	e := &entry{"/etc/init", 10.1234}
+	e := &Entry{Path: "/etc/init", Score: 10.1234}
 	assert.Equal(t, "10.12\t/etc/init", e.String())
+
+	t.Run("Should append metadata columns if present", func(t *testing.T) {
+		e := &Entry{
+			Path:      "/etc/init",
+			Score:     10.1234,
+			LastVisit: time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC),
+			Visits:    3,
+		}
+		assert.Equal(t, "10.12\t/etc/init\t2020-05-01T10:00:00Z\t3\t-", e.String())
+	})
+}
+
+func TestParseEntry(t *testing.T) {
+	t.Run("Should parse autojump-style lines", func(t *testing.T) {
+		e, err := ParseEntry("10.12\t/etc/init")
+		assert.Nil(t, err)
+		assert.Equal(t, Entry{Path: "/etc/init", Score: 10.12}, e)
+	})
+	t.Run("Should parse metadata columns", func(t *testing.T) {
+		e, err := ParseEntry("10.12\t/etc/init\t2020-05-01T10:00:00Z\t3\t2019-01-02T03:04:05Z")
+		assert.Nil(t, err)
+		assert.Equal(t, "/etc/init", e.Path)
+		assert.Equal(t, 3, e.Visits)
+		assert.True(t, time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC).Equal(e.LastVisit))
+		assert.True(t, time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC).Equal(e.FirstSeen))
+	})
+	t.Run("Should round trip through String", func(t *testing.T) {
+		line := "10.12\t/etc/init\t2020-05-01T10:00:00Z\t3\t-"
+		e, err := ParseEntry(line)
+		assert.Nil(t, err)
+		assert.Equal(t, line, e.String())
+	})
+	t.Run("Should allow tabs in paths", func(t *testing.T) {
+		e, err := ParseEntry("10.12\t/tmp/a\tb")
+		assert.Nil(t, err)
+		assert.Equal(t, "/tmp/a\tb", e.Path)
+
+		e, err = ParseEntry("10.12\t/tmp/a\tb\t2020-05-01T10:00:00Z\t3\t-")
+		assert.Nil(t, err)
+		assert.Equal(t, "/tmp/a\tb", e.Path)
+		assert.Equal(t, 3, e.Visits)
+	})
+	t.Run("Should fail on malformed metadata", func(t *testing.T) {
+		_, err := ParseEntry("10.12\t/etc/init\tyesterday\t3\t-")
+		assert.NotNil(t, err)
+	})
+	t.Run("Should ignore carriage returns", func(t *testing.T) {
+		e, err := ParseEntry("10.12\t/etc/init\r")
+		assert.Nil(t, err)
+		assert.Equal(t, "/etc/init", e.Path)
+
+		e, err = ParseEntry("10.12\t/etc/init\t2020-05-01T10:00:00Z\t3\t-\r")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, e.Visits)
+	})
+	t.Run("Should fail on invalid lines", func(t *testing.T) {
+		for _, line := range []string{
+			"",
+			"10",
+			"10\t",
+			"\t/etc/init",
+			"abc\t/etc/init",
+			"NaN\t/etc/init",
+			"Inf\t/etc/init",
+			"-Inf\t/etc/init",
+			"1e400\t/etc/init",
+			"-1\t/etc/init",
+			"10\tetc/init",
+			"10\t/etc/init\t2020-05-01T10:00:00Z\t-3\t-",
+			"10\t\t2020-05-01T10:00:00Z\t3\t-",
+		} {
+			_, err := ParseEntry(line)
+			assert.NotNil(t, err, "%q", line)
+		}
+	})
+}
+
+func FuzzParseEntry(f *testing.F) {
+	for _, line := range []string{
+		"10.12\t/etc/init",
+		"10.12\t/etc/init\t2020-05-01T10:00:00Z\t3\t2019-01-02T03:04:05Z",
+		"10.12\t/tmp/a\tb\t2020-05-01T10:00:00Z\t3\t-",
+		"10\r",
+		"NaN\t/etc/init",
+		"-0\t/",
+	} {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		ent, err := ParseEntry(line)
+		if err != nil {
+			return
+		}
+		if ent.Path == "" || math.IsNaN(ent.Score) || math.IsInf(ent.Score, 0) || ent.Score < 0 || ent.Visits < 0 {
+			t.Fatalf("invalid entry %#v parsed from %q", ent, line)
+		}
+		// Written entries are read back as they are
+		written := ent.String()
+		again, err := ParseEntry(written)
+		if err != nil {
+			t.Fatalf("can't parse %q written from %q: %v", written, line, err)
+		}
+		if again.String() != written {
+			t.Fatalf("%q is read back as %q", written, again.String())
+		}
+	})
+}
+
+func TestRecordVisit(t *testing.T) {
+	first := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	e := &Entry{Path: "/etc/init"}
+	e.recordVisit(first)
+	e.recordVisit(second)
+	assert.Equal(t, 2, e.Visits)
+	assert.Equal(t, second, e.LastVisit)
+	assert.Equal(t, first, e.FirstSeen)
 }
 
 func TestUpdateEntryScore(t *testing.T) {
-	e := &entry{"/etc/init", 0}
+	e := &Entry{Path: "/etc/init", Score: 0}
 	e.updateScore(10)
-	assert.Equal(t, float64(10), e.score)
+	assert.Equal(t, float64(10), e.Score)
 
 	e.updateScore(10)
-	assert.InDelta(t, 14.14, e.score, 0.01)
+	assert.InDelta(t, 14.14, e.Score, 0.01)
+
+	e.updateScore(-10)
+	assert.InDelta(t, 4.14, e.Score, 0.01)
+
+	e.updateScore(-10)
+	assert.Equal(t, float64(0), e.Score, "Score should not be negative")
 }
 
 func TestLoadEntries(t *testing.T) {
@@ -78,12 +208,32 @@ func TestLoadEntries(t *testing.T) {
 		assert.Len(t, entries, 3)
 		paths := make([]string, len(entries))
 		for i, e := range entries {
-			paths[i] = e.val
+			paths[i] = e.Path
 		}
 		assert.Equal(t, []string{"/a/b", "/a", "/c"}, paths)
 	})
 }
 
+func TestLoadEntriesSkipsInvalidLines(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	long := "15\t/" + strings.Repeat("x", 100000)
+	content := "20\t/a\r\n\n  \n10\n" + long + "\nNaN\t/b\n12.5\t/c"
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, os.WriteFile(store.path, []byte(content), 0640))
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"/a", long[3:], "/c"}, paths)
+}
+
 func TestPreprocessPath(t *testing.T) {
 	path, err := preprocessPath("/abc/")
 	assert.Nil(t, err)
@@ -93,6 +243,9 @@ func TestPreprocessPath(t *testing.T) {
 	pwd, err := os.Getwd()
 	assert.Nil(t, err)
 	assert.Equal(t, filepath.Join(pwd, "abc"), path)
+	path, err = preprocessPath("/")
+	assert.Nil(t, err)
+	assert.Equal(t, "/", path, "The root is not the current directory")
 }
 
 func TestClearNotExistDirs(t *testing.T) {
@@ -111,18 +264,18 @@ func TestClearNotExistDirs(t *testing.T) {
 	var expected []string
 	var entries EntryList
 	for _, c := range cases {
-		e := &entry{val: filepath.Join(dir, c.basename)}
+		e := &Entry{Path: filepath.Join(dir, c.basename)}
 		if c.create {
-			err := os.MkdirAll(e.val, 0644)
+			err := os.MkdirAll(e.Path, 0644)
 			assert.Nil(t, err)
-			expected = append(expected, e.val)
+			expected = append(expected, e.Path)
 		}
 		entries = append(entries, e)
 	}
 	result, changed := clearNotExistDirs(entries)
 	var output []string
 	for _, r := range result {
-		output = append(output, r.val)
+		output = append(output, r.Path)
 	}
 	assert.Equal(t, expected, output)
 	assert.True(t, changed, "Empty dirs get deleted, but changed is false.")
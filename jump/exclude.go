@@ -0,0 +1,102 @@
+package jump
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const regexPrefix = "re:"
+
+// Excludes is a set of patterns of paths that should never be recorded.
+type Excludes []func(path string) bool
+
+// ParseExcludes compiles exclude patterns.
+//
+// Patterns starting with "re:" are regular expressions matched against the whole path.
+// Other patterns are globs in the syntax of filepath.Match.
+// Globs containing a path separator, like "/tmp" or "/mnt/*/secrets",
+// exclude the matched directories and everything under them.
+// Globs without one, like "node_modules" or "*.build", are matched against each part of the path.
+func ParseExcludes(patterns []string) (Excludes, error) {
+	var excludes Excludes
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if strings.HasPrefix(p, regexPrefix) {
+			re, err := regexp.Compile(strings.TrimPrefix(p, regexPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+			}
+			excludes = append(excludes, re.MatchString)
+			continue
+		}
+		// Validate the glob once, so that errors can be ignored when matching
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+		if strings.ContainsRune(p, os.PathSeparator) {
+			excludes = append(excludes, globPrefixMatcher(filepath.Clean(p)))
+		} else {
+			excludes = append(excludes, globPartMatcher(p))
+		}
+	}
+	return excludes, nil
+}
+
+func globPrefixMatcher(pattern string) func(string) bool {
+	return func(path string) bool {
+		for {
+			if matched, _ := filepath.Match(pattern, path); matched {
+				return true
+			}
+			parent := filepath.Dir(path)
+			if parent == path {
+				return false
+			}
+			path = parent
+		}
+	}
+}
+
+func globPartMatcher(pattern string) func(string) bool {
+	return func(path string) bool {
+		for _, part := range strings.Split(path, string(os.PathSeparator)) {
+			if matched, _ := filepath.Match(pattern, part); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SplitExcludes splits a list of patterns separated by os.PathListSeparator,
+// as found in the SHONENJUMP_EXCLUDE environment variable.
+// The separator of the "re:" prefix is kept, but regular expressions can't contain it elsewhere.
+func SplitExcludes(s string) []string {
+	var patterns []string
+	parts := filepath.SplitList(s)
+	prefix := strings.TrimSuffix(regexPrefix, ":")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == prefix && i+1 < len(parts) {
+			patterns = append(patterns, regexPrefix+parts[i+1])
+			i++
+			continue
+		}
+		patterns = append(patterns, parts[i])
+	}
+	return patterns
+}
+
+// Match tells if path is excluded by any of the patterns.
+func (excludes Excludes) Match(path string) bool {
+	for _, match := range excludes {
+		if match(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package jump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludes(t *testing.T) {
+	excludes, err := ParseExcludes([]string{
+		"/tmp",
+		"/mnt/*/secrets",
+		"node_modules",
+		"*.build",
+		`re:^/home/\w+/\.cache`,
+		"",
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		path     string
+		excluded bool
+	}{
+		{"/tmp", true},
+		{"/tmp/foo/bar", true},
+		{"/tmpfoo", false},
+		{"/mnt/usb/secrets", true},
+		{"/mnt/usb/secrets/keys", true},
+		{"/mnt/usb/photos", false},
+		{"/src/app/node_modules", true},
+		{"/src/app/node_modules/react", true},
+		{"/src/app/node_modules_backup", false},
+		{"/src/app/out.build/bin", true},
+		{"/home/tester/.cache/go", true},
+		{"/home/tester/projects", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.excluded, excludes.Match(test.path), test.path)
+	}
+}
+
+func TestSplitExcludes(t *testing.T) {
+	patterns := SplitExcludes("/tmp:node_modules:re:^/mnt/.*/secrets:*.build")
+	expected := []string{"/tmp", "node_modules", "re:^/mnt/.*/secrets", "*.build"}
+	assert.Equal(t, expected, patterns)
+	assert.Empty(t, SplitExcludes(""))
+}
+
+func TestParseExcludesShouldRejectInvalidPatterns(t *testing.T) {
+	_, err := ParseExcludes([]string{"re:("})
+	assert.NotNil(t, err)
+
+	_, err = ParseExcludes([]string{"[a-"})
+	assert.NotNil(t, err)
+}
+
+func TestExcludedPathsAreNotRecorded(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kept, excluded := filepath.Join(dir, "src"), filepath.Join(dir, "src", "node_modules")
+	assert.Nil(t, os.MkdirAll(excluded, 0740))
+
+	dataPath := filepath.Join(dir, "testEntries")
+	assert.Nil(t, NewStore(dataPath).AddPath(excluded))
+
+	excludes, err := ParseExcludes([]string{"node_modules"})
+	assert.Nil(t, err)
+	store := NewStore(dataPath, WithExcludes(excludes))
+	assert.Nil(t, store.AddPath(kept))
+	assert.Nil(t, store.AddPath(excluded))
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2, "Entries recorded before should be kept until purged")
+
+	removed, err := store.PurgeExcluded()
+	assert.Nil(t, err)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, excluded, removed[0].Path)
+
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, kept, entries[0].Path)
+}
@@ -0,0 +1,77 @@
+package jump
+
+import (
+	"path/filepath"
+)
+
+// SkippedInvalidPath is why a path that matched a query isn't a candidate
+// when it no longer exists.
+const SkippedInvalidPath = "invalid path"
+
+// Explanation tells how a path was found for a query.
+type Explanation struct {
+	Candidate
+	// Rank is the 1-based position of the path among the candidates,
+	// or 0 if it was skipped.
+	Rank int
+	// Skipped tells why the path isn't a candidate.
+	Skipped string
+	// Distance is how far the last part of the path is from the last keyword
+	// for the fuzzy matcher, or -1 if they don't match at all.
+	Distance int
+	// Duplicates lists the matchers that found the path again after Matcher.
+	Duplicates []string
+	// OutrankedBy is the matcher of the first candidate ranked before the path
+	// despite a lower score, as ranked by the scorer of the Finder, if any.
+	OutrankedBy string
+}
+
+// Explain tells about every path found for args, in the order of the matchers
+// like Find, including the ones that are skipped.
+func (f Finder) Explain(entries EntryList, args []string) []*Explanation {
+	var explanations []*Explanation
+	byPath := make(map[string]*Explanation)
+	scores := entryScores(entries)
+	var candidates []Candidate
+	f.walk(entries, args, func(p, matcher string) bool {
+		if ex, ok := byPath[p]; ok {
+			ex.Duplicates = append(ex.Duplicates, matcher)
+			return true
+		}
+		ex := &Explanation{
+			Candidate: Candidate{p, scores[p], matcher},
+			Distance:  fuzzyDistance(p, args),
+		}
+		if isValidPath(p) {
+			candidates = append(candidates, ex.Candidate)
+		} else {
+			ex.Skipped = SkippedInvalidPath
+		}
+		explanations = append(explanations, ex)
+		byPath[p] = ex
+		return true
+	})
+
+	ranks := f.entryRanks(entries)
+	f.rank(candidates, ranks)
+	for i, c := range candidates {
+		ex := byPath[c.Path]
+		ex.Rank = i + 1
+		for _, prev := range candidates[:i] {
+			if ranks[prev.Path] < ranks[c.Path] && prev.Matcher != c.Matcher {
+				ex.OutrankedBy = prev.Matcher
+				break
+			}
+		}
+	}
+	return explanations
+}
+
+// fuzzyDistance is the distance matchFuzzy computes for path.
+func fuzzyDistance(path string, args []string) int {
+	if len(args) == 0 {
+		return -1
+	}
+	_, lastPart := filepath.Split(path)
+	return calculateDiff(args[len(args)-1], lastPart)
+}
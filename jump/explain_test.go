@@ -0,0 +1,74 @@
+package jump
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return p != "/gone/baz"
+	}
+
+	entries := EntryList{
+		{Path: "/foo/bazar", Score: 30},
+		{Path: "/gone/baz", Score: 20},
+		{Path: "/foo/baz", Score: 5},
+		{Path: "/tmp/bxaxz", Score: 1},
+		{Path: "/tmp/other", Score: 50},
+	}
+	explanations := Finder{}.Explain(entries, []string{"baz"})
+	expected := []*Explanation{
+		{
+			Candidate:  Candidate{"/gone/baz", 20, MatchExactName},
+			Skipped:    SkippedInvalidPath,
+			Duplicates: []string{MatchConsecutive, MatchFuzzy, MatchAnywhere},
+		},
+		{
+			Candidate:  Candidate{"/foo/baz", 5, MatchExactName},
+			Rank:       1,
+			Duplicates: []string{MatchConsecutive, MatchFuzzy, MatchAnywhere},
+		},
+		{
+			Candidate:   Candidate{"/foo/bazar", 30, MatchConsecutive},
+			Rank:        2,
+			Distance:    2,
+			Duplicates:  []string{MatchFuzzy, MatchAnywhere},
+			OutrankedBy: MatchExactName,
+		},
+		{
+			Candidate: Candidate{"/tmp/bxaxz", 1, MatchFuzzy},
+			Rank:      3,
+			Distance:  2,
+		},
+	}
+	assert.Equal(t, expected, explanations)
+
+	// Candidates are the same as with Find
+	var paths []string
+	for _, ex := range explanations {
+		if ex.Rank != 0 {
+			paths = append(paths, ex.Path)
+		}
+	}
+	assert.Equal(t, Finder{}.GetCandidates(entries, []string{"baz"}, 9), paths)
+}
+
+func TestExplainPin(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := EntryList{{Path: "/src/web", Score: 10}}
+	finder := Finder{}.WithPins(Pins{"web": "/opt/site"})
+	explanations := finder.Explain(entries, []string{"web"})
+	assert.Equal(t, 2, len(explanations))
+	assert.Equal(t, Candidate{"/opt/site", 0, MatchPin}, explanations[0].Candidate)
+	assert.Equal(t, -1, explanations[0].Distance)
+	assert.Equal(t, MatchPin, explanations[1].OutrankedBy)
+}
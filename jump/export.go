@@ -0,0 +1,126 @@
+package jump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormats lists the formats supported by WriteDatabase.
+var ExportFormats = []string{FormatAutojump, FormatZ, FormatZoxide, FormatJSON}
+
+// jsonEntry is an entry in FormatJSON.
+type jsonEntry struct {
+	Path      string     `json:"path"`
+	Score     float64    `json:"score"`
+	LastVisit *time.Time `json:"last_visit,omitempty"`
+	Visits    int        `json:"visits,omitempty"`
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// WriteDatabase writes entries, scored by scorer, in the database format of another jumper.
+//
+// Scores are converted to ranks the other way round from ReadDatabase,
+// so that importing the written database gives back the same scores.
+func WriteDatabase(w io.Writer, entries EntryList, format string, scorer Scorer) error {
+	switch format {
+	case FormatAutojump:
+		return writeLines(w, entries, func(e *Entry) string {
+			// autojump doesn't know about the metadata columns
+			score := convertScore(e.Score, scorer, ClassicScorer{})
+			return Entry{Path: e.Path, Score: score}.String()
+		})
+	case FormatZ:
+		return writeLines(w, entries, func(e *Entry) string {
+			return fmt.Sprintf("%s|%s|%d", e.Path, formatRank(toVisits(scorer, e.Score)), lastAccess(e))
+		})
+	case FormatZoxide:
+		return writeZoxide(w, entries, scorer)
+	case FormatJSON:
+		records := make([]jsonEntry, len(entries))
+		for i, e := range entries {
+			records[i] = jsonEntry{
+				Path:      e.Path,
+				Score:     e.Score,
+				LastVisit: optionalTime(e.LastVisit),
+				Visits:    e.Visits,
+				FirstSeen: optionalTime(e.FirstSeen),
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	return fmt.Errorf("unknown format: %q", format)
+}
+
+func writeLines(w io.Writer, entries EntryList, format func(*Entry) string) error {
+	writer := bufio.NewWriter(w)
+	for _, e := range entries {
+		if _, err := fmt.Fprintln(writer, format(e)); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func formatRank(rank float64) string {
+	return strconv.FormatFloat(rank, 'f', -1, 64)
+}
+
+// lastAccess tells the time of the last visit in seconds,
+// which z and zoxide need to age their ranks.
+func lastAccess(e *Entry) int64 {
+	if e.LastVisit.IsZero() {
+		return now().Unix()
+	}
+	return e.LastVisit.Unix()
+}
+
+// writeZoxide writes entries in the binary database format read by readZoxide.
+func writeZoxide(w io.Writer, entries EntryList, scorer Scorer) error {
+	writer := bufio.NewWriter(w)
+	header := struct {
+		Version uint32
+		Count   uint64
+	}{zoxideVersion, uint64(len(entries))}
+	if err := binary.Write(writer, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := binary.Write(writer, binary.LittleEndian, uint64(len(e.Path))); err != nil {
+			return err
+		}
+		if _, err := writer.WriteString(e.Path); err != nil {
+			return err
+		}
+		dir := struct {
+			Rank         float64
+			LastAccessed uint64
+		}{toVisits(scorer, e.Score), uint64(lastAccess(e))}
+		if err := binary.Write(writer, binary.LittleEndian, dir); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// Export writes the entries of the data file in the database format of another jumper.
+func (s Store) Export(w io.Writer, format string) error {
+	entries, err := s.ReadEntries()
+	if err != nil {
+		return err
+	}
+	return WriteDatabase(w, entries, format, s.scorer)
+}
@@ -0,0 +1,119 @@
+package jump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportedEntries() EntryList {
+	visit := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	return EntryList{
+		{Path: "/home/user/projects", Score: 60, LastVisit: visit, Visits: 9, FirstSeen: visit.Add(-time.Hour)},
+		{Path: "/home/user/my|pipe", Score: 28.28, LastVisit: visit, Visits: 2, FirstSeen: visit},
+		{Path: "/home/user/old", Score: 20},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	exportTime := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return exportTime }
+
+	for _, format := range ExportFormats {
+		t.Run(format, func(t *testing.T) {
+			entries := exportedEntries()
+			var buf bytes.Buffer
+			assert.Nil(t, WriteDatabase(&buf, entries, format, ClassicScorer{}))
+
+			imported, invalid, err := ReadDatabase(&buf, format, ClassicScorer{})
+			assert.Nil(t, err)
+			assert.Equal(t, 0, invalid)
+			assert.Equal(t, len(entries), len(imported))
+			for i, e := range entries {
+				assert.Equal(t, e.Path, imported[i].Path)
+				assert.InDelta(t, e.Score, imported[i].Score, 1e-9)
+				switch format {
+				case FormatJSON:
+					assert.True(t, e.LastVisit.Equal(imported[i].LastVisit))
+					assert.True(t, e.FirstSeen.Equal(imported[i].FirstSeen))
+					assert.Equal(t, e.Visits, imported[i].Visits)
+				case FormatZ, FormatZoxide:
+					// Paths never visited get the time of the export
+					want := e.LastVisit
+					if want.IsZero() {
+						want = exportTime
+					}
+					assert.True(t, want.Equal(imported[i].LastVisit))
+				}
+			}
+		})
+	}
+}
+
+func TestExportToAutojump(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteDatabase(&buf, exportedEntries(), FormatAutojump, ClassicScorer{}))
+	// autojump can't read the metadata columns
+	expected := "60.00\t/home/user/projects\n28.28\t/home/user/my|pipe\n20.00\t/home/user/old\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestExportToZ(t *testing.T) {
+	var buf bytes.Buffer
+	entries := EntryList{
+		{Path: "/a", Score: 60, LastVisit: time.Unix(1660000000, 0)},
+		{Path: "/b", Score: -5, LastVisit: time.Unix(1660000000, 0)},
+	}
+	assert.Nil(t, WriteDatabase(&buf, entries, FormatZ, ClassicScorer{}))
+	assert.Equal(t, "/a|9|1660000000\n/b|0|1660000000\n", buf.String())
+}
+
+func TestExportWithFrecency(t *testing.T) {
+	entries := EntryList{{Path: "/a", Score: 9, LastVisit: time.Unix(1660000000, 0)}}
+	var buf bytes.Buffer
+	assert.Nil(t, WriteDatabase(&buf, entries, FormatZ, FrecencyScorer{}))
+	assert.Equal(t, "/a|9|1660000000\n", buf.String())
+
+	buf.Reset()
+	assert.Nil(t, WriteDatabase(&buf, entries, FormatAutojump, FrecencyScorer{}))
+	assert.Equal(t, "60.00\t/a\n", buf.String())
+}
+
+func TestExportToJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteDatabase(&buf, exportedEntries()[1:], FormatJSON, ClassicScorer{}))
+	expected := `[
+  {
+    "path": "/home/user/my|pipe",
+    "score": 28.28,
+    "last_visit": "2023-01-02T03:04:05Z",
+    "visits": 2,
+    "first_seen": "2023-01-02T03:04:05Z"
+  },
+  {
+    "path": "/home/user/old",
+    "score": 20
+  }
+]
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestExportToUnknownFormat(t *testing.T) {
+	err := WriteDatabase(&bytes.Buffer{}, exportedEntries(), FormatFasd, ClassicScorer{})
+	assert.NotNil(t, err)
+}
+
+func TestImportFromJSONSkipsNegativeScores(t *testing.T) {
+	input := `[{"path": "/a", "score": 10}, {"path": "/b", "score": -1}]`
+	entries, invalid, err := ReadDatabase(strings.NewReader(input), FormatJSON, ClassicScorer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, invalid)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "/a", entries[0].Path)
+}
@@ -0,0 +1,247 @@
+package jump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Database formats of other jumpers
+const (
+	FormatAutojump = "autojump"
+	FormatZ        = "z"
+	FormatZLua     = "zlua"
+	FormatFasd     = "fasd"
+	FormatZoxide   = "zoxide"
+	// FormatJSON is a list of entries with all their metadata, written by shonenjump itself
+	FormatJSON = "json"
+)
+
+// ImportFormats lists the formats supported by ReadDatabase.
+var ImportFormats = []string{FormatAutojump, FormatZ, FormatZLua, FormatFasd, FormatZoxide, FormatJSON}
+
+// ImportStats tells what happened to the entries of an imported database.
+type ImportStats struct {
+	// Added counts the paths that were not in the data file yet.
+	Added int
+	// Merged counts the paths already in the data file.
+	Merged int
+	// Skipped counts invalid lines, and paths that don't exist or are excluded.
+	Skipped int
+}
+
+// ReadDatabase reads the database of another jumper in the given format,
+// and converts its ranks to scores of scorer.
+// It also returns the number of records that could not be parsed.
+//
+// z, z.lua, fasd and zoxide all add one to the rank of a path on every visit,
+// so their ranks are converted to the score the path would have after as many
+// visits with DefaultWeight. Scores of autojump are converted the same way from
+// the classic scoring. Scores of FormatJSON are kept as they are, so it has to be
+// read with the scoring it was written with.
+func ReadDatabase(r io.Reader, format string, scorer Scorer) (entries EntryList, invalid int, err error) {
+	switch format {
+	case FormatAutojump:
+		return readLines(r, func(line string) (*Entry, error) {
+			ent, err := ParseEntry(line)
+			ent.Score = convertScore(ent.Score, ClassicScorer{}, scorer)
+			return &ent, err
+		})
+	case FormatZ, FormatZLua, FormatFasd:
+		return readLines(r, func(line string) (*Entry, error) {
+			return parseZLine(line, scorer)
+		})
+	case FormatZoxide:
+		entries, err = readZoxide(r, scorer)
+		return entries, 0, err
+	case FormatJSON:
+		return readJSON(r)
+	}
+	return nil, 0, fmt.Errorf("unknown format: %q", format)
+}
+
+func readLines(r io.Reader, parse func(string) (*Entry, error)) (entries EntryList, invalid int, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		ent, err := parse(line)
+		if err != nil {
+			log.Printf("Failed to parse line: %v", line)
+			invalid++
+			continue
+		}
+		entries = append(entries, ent)
+	}
+	return entries, invalid, scanner.Err()
+}
+
+// rankToEntry converts the rank of a path in z-like databases.
+func rankToEntry(path string, rank float64, lastAccess int64, scorer Scorer) (*Entry, error) {
+	if math.IsNaN(rank) || math.IsInf(rank, 0) || rank < 0 {
+		return nil, fmt.Errorf("invalid rank: %v", rank)
+	}
+	ent := &Entry{
+		Path:   path,
+		Score:  fromVisits(scorer, rank),
+		Visits: int(math.Max(math.Round(rank), 1)),
+	}
+	if lastAccess > 0 {
+		ent.LastVisit = time.Unix(lastAccess, 0)
+	}
+	return ent, nil
+}
+
+// parseZLine parses a line in the format "path|rank|time" shared by z, z.lua and fasd.
+func parseZLine(line string, scorer Scorer) (*Entry, error) {
+	// Split from the right, paths may contain "|"
+	i := strings.LastIndexByte(line, '|')
+	if i == -1 {
+		return nil, fmt.Errorf("invalid line: %q", line)
+	}
+	lastAccess, err := strconv.ParseInt(line[i+1:], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	line = line[:i]
+	i = strings.LastIndexByte(line, '|')
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid line: %q", line)
+	}
+	rank, err := strconv.ParseFloat(line[i+1:], 64)
+	if err != nil {
+		return nil, err
+	}
+	return rankToEntry(line[:i], rank, lastAccess, scorer)
+}
+
+const zoxideVersion = 3
+
+// readZoxide reads the binary database of zoxide, which is a version number
+// followed by a list of paths with their ranks and times of last access,
+// all encoded with bincode.
+func readZoxide(r io.Reader, scorer Scorer) (EntryList, error) {
+	reader := bufio.NewReader(r)
+	var version uint32
+	if err := binary.Read(reader, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != zoxideVersion {
+		return nil, fmt.Errorf("unsupported zoxide database version: %d", version)
+	}
+	var count uint64
+	if err := binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+	var entries EntryList
+	for i := uint64(0); i < count; i++ {
+		var pathLen uint64
+		if err := binary.Read(reader, binary.LittleEndian, &pathLen); err != nil {
+			return nil, err
+		}
+		if pathLen > math.MaxInt16 {
+			return nil, fmt.Errorf("invalid zoxide database: path of %d bytes", pathLen)
+		}
+		path := make([]byte, pathLen)
+		if _, err := io.ReadFull(reader, path); err != nil {
+			return nil, err
+		}
+		var dir struct {
+			Rank         float64
+			LastAccessed uint64
+		}
+		if err := binary.Read(reader, binary.LittleEndian, &dir); err != nil {
+			return nil, err
+		}
+		ent, err := rankToEntry(string(path), dir.Rank, int64(dir.LastAccessed), scorer)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ent)
+	}
+	return entries, nil
+}
+
+func readJSON(r io.Reader) (entries EntryList, invalid int, err error) {
+	var records []jsonEntry
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, 0, err
+	}
+	for _, rec := range records {
+		if rec.Score < 0 {
+			invalid++
+			continue
+		}
+		ent := &Entry{Path: rec.Path, Score: rec.Score, Visits: rec.Visits}
+		if rec.LastVisit != nil {
+			ent.LastVisit = *rec.LastVisit
+		}
+		if rec.FirstSeen != nil {
+			ent.FirstSeen = *rec.FirstSeen
+		}
+		entries = append(entries, ent)
+	}
+	return entries, invalid, nil
+}
+
+// Import merges the database of another jumper into the data file.
+func (s Store) Import(r io.Reader, format string) (ImportStats, error) {
+	var stats ImportStats
+	imported, invalid, err := ReadDatabase(r, format, s.scorer)
+	if err != nil {
+		return stats, err
+	}
+	stats.Skipped = invalid
+	err = s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		t := now()
+		for _, imp := range imported {
+			if imp.Path == "" {
+				stats.Skipped++
+				continue
+			}
+			path, err := preprocessPath(imp.Path)
+			if err != nil || !isValidPath(path) || s.excludes.Match(path) {
+				stats.Skipped++
+				continue
+			}
+			if entries.find(path) == nil {
+				stats.Added++
+			} else {
+				stats.Merged++
+			}
+			// Same as EntryList.Update, but sorting only once in the end
+			var ent *Entry
+			entries, ent = entries.add(path)
+			combine(s.scorer, ent, imp.Score, t)
+			mergeMetadata(ent, imp)
+		}
+		entries.sortByRank(s.scorer, t)
+		return s.saveEntries(entries, rejected)
+	})
+	return stats, err
+}
+
+// mergeMetadata adds the visits of other to e,
+// keeping the latest time of last visit and the earliest time of first visit.
+func mergeMetadata(e *Entry, other *Entry) {
+	e.Visits += other.Visits
+	if other.LastVisit.After(e.LastVisit) {
+		e.LastVisit = other.LastVisit
+	}
+	if !other.FirstSeen.IsZero() && (e.FirstSeen.IsZero() || other.FirstSeen.Before(e.FirstSeen)) {
+		e.FirstSeen = other.FirstSeen
+	}
+}
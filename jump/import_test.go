@@ -0,0 +1,179 @@
+package jump
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var importFixtures = map[string]string{
+	FormatAutojump: "autojump.txt",
+	FormatZ:        "z.txt",
+	FormatZLua:     "zlua.txt",
+	FormatFasd:     "fasd.txt",
+	FormatZoxide:   "zoxide.db",
+	FormatJSON:     "shonenjump.json",
+}
+
+func openFixture(t *testing.T, format string) *os.File {
+	f, err := os.Open(filepath.Join("testdata", "import", importFixtures[format]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestReadDatabase(t *testing.T) {
+	for _, format := range ImportFormats {
+		t.Run(format, func(t *testing.T) {
+			f := openFixture(t, format)
+			defer f.Close()
+
+			entries, invalid, err := ReadDatabase(f, format, ClassicScorer{})
+			assert.Nil(t, err)
+			assert.Equal(t, 3, len(entries))
+			if format == FormatZoxide {
+				assert.Equal(t, 0, invalid)
+			} else {
+				assert.Equal(t, 1, invalid)
+			}
+			assert.Equal(t, "/home/user/projects", entries[0].Path)
+			assert.Equal(t, "/home/user/projects/shonenjump", entries[1].Path)
+			assert.Equal(t, "/home/user/gone", entries[2].Path)
+		})
+	}
+}
+
+func TestReadDatabaseConvertsRanks(t *testing.T) {
+	entries, _, err := ReadDatabase(strings.NewReader("/a|9|1660000000\n/b|0.2|0\n"), FormatZ, ClassicScorer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 3*DefaultWeight, entries[0].Score)
+	assert.Equal(t, 9, entries[0].Visits)
+	assert.True(t, entries[0].LastVisit.Equal(time.Unix(1660000000, 0)))
+	// Every path has been visited at least once
+	assert.Equal(t, 1, entries[1].Visits)
+	assert.True(t, entries[1].LastVisit.IsZero())
+}
+
+func TestReadDatabaseConvertsRanksToFrecency(t *testing.T) {
+	entries, _, err := ReadDatabase(strings.NewReader("/a|9|1660000000\n"), FormatZ, FrecencyScorer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 9.0, entries[0].Score)
+
+	// Scores of autojump are converted from the classic scoring
+	entries, _, err = ReadDatabase(strings.NewReader("60\t/a\n"), FormatAutojump, FrecencyScorer{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 9.0, entries[0].Score, 1e-9)
+}
+
+func TestReadDatabaseKeepsPipesInPaths(t *testing.T) {
+	entries, invalid, err := ReadDatabase(strings.NewReader("/a|b|2|1660000000\n"), FormatZ, ClassicScorer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, invalid)
+	assert.Equal(t, "/a|b", entries[0].Path)
+}
+
+func TestReadDatabaseRejectsInvalidRanks(t *testing.T) {
+	input := "/a|-1|1660000000\n/b|NaN|1660000000\n/c|+Inf|1660000000\n"
+	entries, invalid, err := ReadDatabase(strings.NewReader(input), FormatFasd, ClassicScorer{})
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, 3, invalid)
+}
+
+func TestReadDatabaseOfUnknownFormat(t *testing.T) {
+	_, _, err := ReadDatabase(strings.NewReader(""), "fastcd", ClassicScorer{})
+	assert.NotNil(t, err)
+}
+
+func TestReadZoxideOfUnsupportedVersion(t *testing.T) {
+	data := []byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	_, _, err := ReadDatabase(bytes.NewReader(data), FormatZoxide, ClassicScorer{})
+	assert.NotNil(t, err)
+}
+
+func TestReadZoxideOfTruncatedDatabase(t *testing.T) {
+	f := openFixture(t, FormatZoxide)
+	defer f.Close()
+	data := new(bytes.Buffer)
+	_, err := data.ReadFrom(f)
+	assert.Nil(t, err)
+
+	_, _, err = ReadDatabase(bytes.NewReader(data.Bytes()[:data.Len()-4]), FormatZoxide, ClassicScorer{})
+	assert.NotNil(t, err)
+}
+
+func TestImport(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return p != "/home/user/gone"
+	}
+
+	for _, format := range ImportFormats {
+		t.Run(format, func(t *testing.T) {
+			dir, err := os.MkdirTemp("", "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			store := NewStore(filepath.Join(dir, "data.txt"))
+			lastVisit := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+			existing := EntryList{
+				{Path: "/home/user/projects", Score: 40, Visits: 2, LastVisit: lastVisit, FirstSeen: lastVisit},
+			}
+			assert.Nil(t, store.saveEntries(existing, nil))
+
+			f := openFixture(t, format)
+			defer f.Close()
+			stats, err := store.Import(f, format)
+			assert.Nil(t, err)
+			skipped := 2
+			if format == FormatZoxide {
+				skipped = 1
+			}
+			assert.Equal(t, ImportStats{Added: 1, Merged: 1, Skipped: skipped}, stats)
+
+			entries, err := store.ReadEntries()
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(entries))
+			merged := entries.find("/home/user/projects")
+			assert.NotNil(t, merged)
+			assert.Greater(t, merged.Score, 40.0)
+			// The latest visit and the earliest first visit are kept
+			assert.True(t, merged.LastVisit.Equal(lastVisit))
+			assert.True(t, merged.FirstSeen.Equal(lastVisit))
+			assert.NotNil(t, entries.find("/home/user/projects/shonenjump"))
+		})
+	}
+}
+
+func TestImportSkipsExcludedPaths(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	excludes, err := ParseExcludes([]string{"/home/user/projects/*"})
+	assert.Nil(t, err)
+	store := NewStore(filepath.Join(dir, "data.txt"), WithExcludes(excludes))
+
+	f := openFixture(t, FormatZ)
+	defer f.Close()
+	stats, err := store.Import(f, FormatZ)
+	assert.Nil(t, err)
+	assert.Equal(t, ImportStats{Added: 2, Merged: 0, Skipped: 2}, stats)
+}
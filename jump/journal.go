@@ -0,0 +1,197 @@
+package jump
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultJournalLimit is the size of the journal, in bytes, past which
+// it is folded into the data file by AddPath. That's about a hundred visits.
+const defaultJournalLimit = 8 << 10
+
+// The journal keeps the visits recorded by AddPath until they are folded into
+// the data file, so that adding a path only appends a line to a small file
+// instead of rewriting the whole data file.
+// Each line holds the time of a visit, its weight and the visited path.
+//
+// Before the data file is replaced by the one the journal is folded into,
+// a line of journalFolded and the checksum of the new data file is appended to
+// the journal. If the journal is left behind once the data file is replaced,
+// like when the process is killed before removing it, the visits up to that line
+// are known to be in the data file already, and aren't applied twice.
+const journalFolded = "folded"
+
+func (s Store) journalPath() string {
+	return s.path + ".journal"
+}
+
+// journalVisit is a visit recorded in the journal.
+type journalVisit struct {
+	path   string
+	weight float64
+	t      time.Time
+}
+
+func (v journalVisit) String() string {
+	return fmt.Sprintf("%s\t%s\t%s", v.t.Format(time.RFC3339Nano), strconv.FormatFloat(v.weight, 'g', -1, 64), v.path)
+}
+
+func parseJournalVisit(s string) (v journalVisit, err error) {
+	parts := strings.SplitN(s, "\t", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return v, fmt.Errorf("invalid journal line: %q", s)
+	}
+	if v.t, err = time.Parse(time.RFC3339Nano, parts[0]); err != nil {
+		return
+	}
+	if v.weight, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return
+	}
+	v.path = parts[2]
+	return v, nil
+}
+
+// appendJournal records a visit in the journal and returns the size of the journal.
+// It has to be called with the lock held.
+func (s Store) appendJournal(v journalVisit) (int64, error) {
+	file, err := os.OpenFile(s.journalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, v); err != nil {
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// hasJournal tells if there are visits waiting to be folded into the data file.
+func (s Store) hasJournal() bool {
+	info, err := os.Stat(s.journalPath())
+	return err == nil && info.Size() > 0
+}
+
+// readJournal returns the visits of the journal in the order they happened,
+// leaving out those already folded into the data file.
+// Lines that can't be parsed, like the last one of an interrupted write, are skipped.
+func (s Store) readJournal() ([]journalVisit, error) {
+	file, err := os.Open(s.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var visits []journalVisit
+	var checksum string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, journalFolded+"\t") {
+			if checksum == "" {
+				if checksum, err = s.dataChecksum(); err != nil {
+					return nil, err
+				}
+			}
+			// Otherwise replacing the data file failed, and the visits still count
+			if strings.TrimPrefix(line, journalFolded+"\t") == checksum {
+				visits = nil
+			}
+			continue
+		}
+		v, err := parseJournalVisit(line)
+		if err != nil {
+			log.Printf("Failed to parse journal line: %v", line)
+			continue
+		}
+		visits = append(visits, v)
+	}
+	return visits, scanner.Err()
+}
+
+// markFolded records in the journal that its visits are folded into
+// a data file of the given checksum.
+// It starts a new line, in case the last one is an interrupted write.
+func (s Store) markFolded(checksum string) error {
+	file, err := os.OpenFile(s.journalPath(), os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(file, "\n%s\t%s\n", journalFolded, checksum); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// dataChecksum returns the checksum of the data file, as recorded by markFolded.
+func (s Store) dataChecksum() (string, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// clearJournal drops the visits of the journal, once they are saved in the data file.
+func (s Store) clearJournal() error {
+	if err := os.Remove(s.journalPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// replay applies visits to entries in order. The entries end up with the same
+// scores as if every visit had been added to the data file one after the other.
+func (s Store) replay(entries EntryList, visits []journalVisit) EntryList {
+	// Saving the first visit would have dropped the paths that no longer exist
+	var valid EntryList
+	for _, e := range entries {
+		if isValidPath(e.Path) {
+			valid = append(valid, e)
+		}
+	}
+	entries = valid
+	for _, v := range visits {
+		visitor := s
+		visitor.weight = v.weight
+		entries = visitor.visit(entries, v.path, v.t)
+		roundEntries(entries)
+	}
+	return entries
+}
+
+// roundEntries rounds scores and times the way writing them to the data file
+// and reading them back does.
+func roundEntries(entries EntryList) {
+	for _, e := range entries {
+		e.Score, _ = strconv.ParseFloat(strconv.FormatFloat(e.Score, 'f', 2, 64), 64)
+		e.LastVisit = e.LastVisit.Truncate(time.Second)
+		e.FirstSeen = e.FirstSeen.Truncate(time.Second)
+	}
+}
@@ -0,0 +1,180 @@
+package jump
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalKeepsScoresOfSequentialAdds(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var paths []string
+	for _, name := range []string{"a", "b", "c"} {
+		p := filepath.Join(dir, name)
+		assert.Nil(t, os.Mkdir(p, 0740))
+		paths = append(paths, p)
+	}
+	initial := fmt.Sprintf("100.00\t%s\n35.50\t%s\t2020-05-01T10:00:00Z\t3\t2020-04-01T10:00:00Z\n12.00\t/missing\n", paths[1], paths[2])
+
+	origNow := now
+	defer func() { now = origNow }()
+
+	for name, sc := range scorers {
+		t.Run(name, func(t *testing.T) {
+			read := func(journalLimit int64) map[string]string {
+				store := NewStore(filepath.Join(dir, fmt.Sprintf("%s-%d", name, journalLimit)), WithScorer(sc), WithWeight(30))
+				store.journalLimit = journalLimit
+				assert.Nil(t, os.WriteFile(store.path, []byte(initial), 0640))
+				visitedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+				now = func() time.Time { return visitedAt }
+				for i := 0; i < 100; i++ {
+					visitedAt = visitedAt.Add(37*time.Minute + 123*time.Millisecond)
+					assert.Nil(t, store.AddPath(paths[i*i%len(paths)]))
+				}
+				entries, err := store.ReadEntries()
+				assert.Nil(t, err)
+				lines := make(map[string]string)
+				for _, e := range entries {
+					lines[e.Path] = e.String()
+				}
+				return lines
+			}
+			assert.Equal(t, read(0), read(1<<20))
+		})
+	}
+}
+
+func TestJournalIsFoldedPastLimit(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	store.journalLimit = 200
+	assert.Nil(t, store.AddPath(dir))
+	assert.True(t, store.hasJournal())
+	_, err = os.Stat(store.path)
+	assert.True(t, os.IsNotExist(err), "The data file isn't written by every visit")
+
+	for store.hasJournal() {
+		assert.Nil(t, store.AddPath(dir))
+	}
+	entries, _, err := store.readDataFile()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Greater(t, entries[0].Visits, 1)
+}
+
+func TestJournalIsFoldedByOtherChanges(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	top, err := store.GetTopPath(".")
+	assert.Nil(t, err)
+	assert.Equal(t, ".", top, "An empty data file has no top path")
+
+	assert.Nil(t, store.AddPath(a))
+	top, err = store.GetTopPath("")
+	assert.Nil(t, err)
+	assert.Equal(t, a, top, "Visits in the journal are seen before being folded")
+	assert.False(t, store.hasJournal(), "Reading the entries folds the journal")
+
+	// Lines of interrupted writes are skipped
+	assert.Nil(t, store.AddPath(a))
+	file, err := os.OpenFile(store.journalPath(), os.O_WRONLY|os.O_APPEND, 0640)
+	assert.Nil(t, err)
+	_, err = file.WriteString("2020-05-01T10:00")
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	_, err = store.Adjust(b, 100)
+	assert.Nil(t, err)
+	assert.False(t, store.hasJournal())
+	entries, _, err := store.readDataFile()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, b, entries[0].Path)
+	assert.Equal(t, 2, entries.find(a).Visits)
+}
+
+func TestJournalLeftBehindByFold(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, store.AddPath(a))
+	assert.Nil(t, store.AddPath(a))
+	journal, err := os.ReadFile(store.journalPath())
+	assert.Nil(t, err)
+	folded, err := store.ReadEntries()
+	assert.Nil(t, err)
+	checksum, err := store.dataChecksum()
+	assert.Nil(t, err)
+
+	// The data file is replaced, but the process is killed before removing the journal
+	leftover := fmt.Sprintf("%s\n%s\t%s\n", journal, journalFolded, checksum)
+	assert.Nil(t, os.WriteFile(store.journalPath(), []byte(leftover), 0640))
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, folded[0].String(), entries[0].String(), "Folded visits aren't applied twice")
+	assert.Equal(t, 2, entries.find(a).Visits)
+
+	// Visits following the fold still count
+	assert.Nil(t, os.WriteFile(store.journalPath(), []byte(leftover), 0640))
+	assert.Nil(t, store.AddPath(b))
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, entries.find(a).Visits)
+	assert.Equal(t, 1, entries.find(b).Visits)
+
+	// Visits of a fold that failed to replace the data file still count
+	assert.Nil(t, store.AddPath(b))
+	journal, err = os.ReadFile(store.journalPath())
+	assert.Nil(t, err)
+	failed := fmt.Sprintf("%s\n%s\t%s\n", journal, journalFolded, checksum)
+	assert.Nil(t, os.WriteFile(store.journalPath(), []byte(failed), 0640))
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, entries.find(b).Visits)
+}
+
+func TestParseJournalVisit(t *testing.T) {
+	v := journalVisit{"/tmp/a\tb", 12.5, time.Date(2020, 5, 1, 10, 0, 0, 123, time.UTC)}
+	parsed, err := parseJournalVisit(v.String())
+	assert.Nil(t, err)
+	assert.Equal(t, v.path, parsed.path)
+	assert.Equal(t, v.weight, parsed.weight)
+	assert.True(t, v.t.Equal(parsed.t))
+
+	for _, line := range []string{"", "2020-05-01T10:00:00Z\t20", "2020-05-01T10:00:00Z\t20\t", "now\t20\t/tmp", "2020-05-01T10:00:00Z\tx\t/tmp"} {
+		_, err := parseJournalVisit(line)
+		assert.NotNil(t, err, line)
+	}
+}
@@ -0,0 +1,58 @@
+package jump
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultLockTimeout = 5 * time.Second
+	lockRetryInterval  = 10 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when the data file stays locked by another
+// process for longer than the store is willing to wait.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+type fileLock struct {
+	file *os.File
+}
+
+// acquireLock takes an exclusive advisory lock on the file at path,
+// creating it if necessary, and retries until timeout has elapsed.
+func acquireLock(path string, timeout time.Duration) (*fileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0740); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			return &fileLock{file}, nil
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%w on %s after %v", ErrLockTimeout, path, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (l *fileLock) Close() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,19 @@
+//go:build !unix && !windows
+
+package jump
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Concurrent processes would overwrite each other's changes without a lock,
+// so stores can't be changed on platforms that can't lock files.
+func tryLockFile(file *os.File) (bool, error) {
+	return false, fmt.Errorf("locking files is not supported on %s", runtime.GOOS)
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -1,6 +1,7 @@
 package jump
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -10,39 +11,197 @@ import (
 )
 
 const (
+	// MaxCompleteOptions is the number of candidates offered for tab completion.
 	MaxCompleteOptions = 9
 )
 
-type matcher func([]*entry, []string) []string
+// Names of the matchers
+const (
+	MatchExactName   = "exact"
+	MatchConsecutive = "consecutive"
+	MatchFuzzy       = "fuzzy"
+	MatchAnywhere    = "anywhere"
+	// MatchSubsequence scores the paths containing the characters of the query in order,
+	// like fzf does. It isn't one of DefaultMatchers.
+	MatchSubsequence = "subsequence"
+)
+
+// DefaultMatchers are the matchers used by GetCandidates,
+// from the strictest to the loosest.
+var DefaultMatchers = []string{MatchExactName, MatchConsecutive, MatchFuzzy, MatchAnywhere}
+
+type matcher func(EntryList, []string) []string
+
+func lookupMatcher(name string) (matcher, bool) {
+	switch name {
+	case MatchExactName:
+		return matchExactName, true
+	case MatchConsecutive:
+		return matchConsecutive, true
+	case MatchFuzzy:
+		return matchFuzzy, true
+	case MatchAnywhere:
+		return matchAnywhere, true
+	case MatchSubsequence:
+		return matchSubsequence, true
+	}
+	return nil, false
+}
+
+// Scopes restricting the paths a Finder finds to those related to a base directory
+const (
+	ScopeChild   = "child"
+	ScopeParent  = "parent"
+	ScopeSibling = "sibling"
+)
+
+// isUnder tells if path is a directory under base, but not base itself.
+func isUnder(path, base string) bool {
+	if !strings.HasSuffix(base, string(os.PathSeparator)) {
+		base += string(os.PathSeparator)
+	}
+	return len(path) > len(base) && strings.HasPrefix(path, base)
+}
+
+// inScope tells if path is in the scope relative to base.
+func inScope(path, scope, base string) bool {
+	switch scope {
+	case ScopeChild:
+		return isUnder(path, base)
+	case ScopeParent:
+		return isUnder(base, path)
+	case ScopeSibling:
+		return path != base && filepath.Dir(path) == filepath.Dir(base)
+	}
+	return true
+}
+
+// Finder finds the paths matching queries.
+// The zero value uses DefaultMatchers and RankingPriority.
+type Finder struct {
+	matchers   []string
+	pins       Pins
+	completing bool
+	scope      string
+	base       string
+	ranking    string
+	weights    map[string]float64
+	scorer     Scorer
+}
+
+// NewFinder creates a Finder trying the named matchers in order.
+func NewFinder(matchers []string) (Finder, error) {
+	if len(matchers) == 0 {
+		return Finder{}, fmt.Errorf("no matchers given")
+	}
+	for _, name := range matchers {
+		if _, ok := lookupMatcher(name); !ok {
+			return Finder{}, fmt.Errorf("unknown matcher: %q", name)
+		}
+	}
+	return Finder{matchers: matchers}, nil
+}
+
+// WithPins returns a copy of f that finds the path pinned to a keyword
+// before trying its matchers.
+func (f Finder) WithPins(pins Pins) Finder {
+	f.pins = pins
+	return f
+}
+
+// Completing returns a copy of f listing the candidates of a keyword being completed,
+// which also finds the paths pinned to the keywords it starts.
+func (f Finder) Completing() Finder {
+	f.completing = true
+	return f
+}
+
+// WithScope returns a copy of f that only finds the children, parents
+// or siblings of base, depending on scope.
+func (f Finder) WithScope(scope, base string) (Finder, error) {
+	switch scope {
+	case ScopeChild, ScopeParent, ScopeSibling:
+	default:
+		return f, fmt.Errorf("unknown scope: %q", scope)
+	}
+	base, err := preprocessPath(base)
+	if err != nil {
+		return f, err
+	}
+	f.scope = scope
+	f.base = base
+	return f, nil
+}
+
+// strict returns a copy of f that only uses its exact name and consecutive
+// matchers, and no pins, to find the paths keywords were clearly meant for.
+func (f Finder) strict() Finder {
+	names := f.matchers
+	if names == nil {
+		names = DefaultMatchers
+	}
+	var strict []string
+	for _, name := range names {
+		if name == MatchExactName || name == MatchConsecutive {
+			strict = append(strict, name)
+		}
+	}
+	if len(strict) == 0 {
+		strict = []string{MatchExactName, MatchConsecutive}
+	}
+	f.matchers = strict
+	f.pins = nil
+	return f
+}
+
+// inScope filters entries to those in the scope of f.
+func (f Finder) inScope(entries EntryList) EntryList {
+	if f.scope == "" {
+		return entries
+	}
+	var filtered EntryList
+	for _, e := range entries {
+		if inScope(e.Path, f.scope, f.base) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
 
-func BestGuess(entries []*entry, args []string) string {
-	candidates := GetCandidates(entries, args, 1)
+// BestGuess returns the path that best matches args, or "." if nothing matches.
+func BestGuess(entries EntryList, args []string) string {
+	return Finder{}.BestGuess(entries, args)
+}
+
+// BestGuess returns the path that best matches args, or "." if nothing matches.
+func (f Finder) BestGuess(entries EntryList, args []string) string {
+	candidates := f.GetCandidates(entries, args, 1)
 	if len(candidates) > 0 {
 		return candidates[0]
 	}
 	return "."
 }
 
-var matchExactName = func(entries []*entry, args []string) (matches []string) {
+var matchExactName = func(entries EntryList, args []string) (matches []string) {
 	if len(args) != 1 {
 		return
 	}
 	q := args[0]
 	for _, e := range entries {
-		if _, name := path.Split(e.val); name == q {
-			matches = append(matches, e.val)
+		if _, name := path.Split(e.Path); name == q {
+			matches = append(matches, e.Path)
 		}
 	}
 	return
 }
 
-var matchConsecutive = func(entries []*entry, args []string) []string {
+var matchConsecutive = func(entries EntryList, args []string) []string {
 	nArgs := len(args)
 	var matches []string
 
 loop_entries:
 	for _, e := range entries {
-		parts := strings.Split(e.val, string(os.PathSeparator))
+		parts := strings.Split(e.Path, string(os.PathSeparator))
 		parts = parts[1:]
 		for i, j := len(parts)-1, nArgs-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
 			if !strings.Contains(
@@ -52,30 +211,33 @@ loop_entries:
 				continue loop_entries
 			}
 		}
-		matches = append(matches, e.val)
+		matches = append(matches, e.Path)
 	}
 	return matches
 }
 
-var matchFuzzy = func(entries []*entry, args []string) []string {
+var matchFuzzy = func(entries EntryList, args []string) []string {
 	var matches []string
+	if len(args) == 0 {
+		return matches
+	}
 	// Only match the last part
 	arg := args[len(args)-1]
 	distanceThreshold := len(arg) * 2
 	for _, e := range entries {
-		_, lastPart := filepath.Split(e.val)
+		_, lastPart := filepath.Split(e.Path)
 		diff := calculateDiff(arg, lastPart)
 		if diff == -1 {
 			continue
 		}
 		if diff < distanceThreshold {
-			matches = append(matches, e.val)
+			matches = append(matches, e.Path)
 		}
 	}
 	return matches
 }
 
-var matchAnywhere = func(entries []*entry, args []string) []string {
+var matchAnywhere = func(entries EntryList, args []string) []string {
 	var matches []string
 	any := ".*"
 	regexParts := []string{"(?i)", any, strings.Join(args, any), any}
@@ -87,34 +249,101 @@ var matchAnywhere = func(entries []*entry, args []string) []string {
 	}
 
 	for _, e := range entries {
-		if pattern.MatchString(e.val) {
-			matches = append(matches, e.val)
+		if pattern.MatchString(e.Path) {
+			matches = append(matches, e.Path)
 		}
 	}
 
 	return matches
 }
 
-func GetCandidates(entries []*entry, args []string, limit int) []string {
-	candidates := make([]string, 0, limit)
+// GetCandidates returns up to limit existing paths matching args.
+// The matchers are tried from the strictest to the loosest,
+// the paths found by each of them are in the order of entries.
+func GetCandidates(entries EntryList, args []string, limit int) []string {
+	return Finder{}.GetCandidates(entries, args, limit)
+}
+
+// GetCandidates returns up to limit existing paths matching args.
+// Only the paths in the scope of f are considered, if it has one.
+// The paths pinned to a query of a single keyword, or to keywords starting with it, come first.
+// Then the matchers of f are tried in order,
+// the paths found by each of them are in the order of entries.
+// With RankingCombined, these paths are then sorted by their combined scores.
+func (f Finder) GetCandidates(entries EntryList, args []string, limit int) []string {
+	candidates := f.Find(entries, args, limit)
+	paths := make([]string, len(candidates))
+	for i, c := range candidates {
+		paths[i] = c.Path
+	}
+	return paths
+}
+
+// Candidate is a path matching a query.
+type Candidate struct {
+	Path  string
+	Score float64
+	// Matcher is the name of the matcher that found the path.
+	Matcher string
+}
+
+// Find works like GetCandidates, but tells more about the candidates.
+func (f Finder) Find(entries EntryList, args []string, limit int) []Candidate {
+	candidates := make([]Candidate, 0, limit)
 	seen := make(map[string]bool, limit)
-	matchers := []matcher{matchExactName, matchConsecutive, matchFuzzy, matchAnywhere}
-	for _, m := range matchers {
-		paths := m(entries, args)
-		for _, p := range paths {
-			if seen[p] || !isValidPath(p) {
-				continue
-			}
-			candidates = append(candidates, p)
-			seen[p] = true
-			if len(candidates) >= limit {
-				return candidates
-			}
+	scores := entryScores(entries)
+	var ranks map[string]float64
+	if f.combined() {
+		ranks = f.entryRanks(entries)
+	}
+	f.walk(entries, args, func(p, matcher string) bool {
+		if seen[p] || !isValidPath(p) {
+			return true
 		}
+		candidates = append(candidates, Candidate{p, scores[p], matcher})
+		seen[p] = true
+		// All candidates are needed to rank them by combined scores
+		return f.combined() || len(candidates) < limit
+	})
+	f.rank(candidates, ranks)
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
 	}
 	return candidates
 }
 
+func entryScores(entries EntryList) map[string]float64 {
+	scores := make(map[string]float64, len(entries))
+	for _, e := range entries {
+		scores[e.Path] = e.Score
+	}
+	return scores
+}
+
+// walk calls visit with every path found for args, in order, along with the
+// name of the matcher that found it, until visit returns false.
+// The same path can be found by several matchers, and may not exist.
+func (f Finder) walk(entries EntryList, args []string, visit func(path, matcher string) bool) {
+	entries = f.inScope(entries)
+	for _, p := range f.pins.lookup(args, f.completing) {
+		if inScope(p, f.scope, f.base) && !visit(p, MatchPin) {
+			return
+		}
+	}
+	names := f.matchers
+	if names == nil {
+		names = DefaultMatchers
+	}
+	for _, name := range names {
+		m, _ := lookupMatcher(name)
+		for _, p := range m(entries, args) {
+			if !visit(p, name) {
+				return
+			}
+		}
+	}
+}
+
 func calculateDiff(source, target string) int {
 	lenDiff := len(target) - len(source)
 	if lenDiff < 0 {
@@ -23,16 +23,16 @@ func BenchmarkGetCandidates(b *testing.B) {
 	assert.Empty(b, candidates)
 }
 
-func generateEntries() []*entry {
+func generateEntries() []*Entry {
 	paths := []string{
 		"/home/tester", "/home/tester/projects",
 		"/foo/bar/baz", "/foo/bazar",
 		"/tmp", "/foo/gxxbazabc",
 		"/tmp/abc", "/tmp/def",
 	}
-	var entries []*entry
+	var entries []*Entry
 	for _, p := range paths {
-		entries = append(entries, &entry{p, 1.0})
+		entries = append(entries, &Entry{Path: p, Score: 1.0})
 	}
 	return entries
 }
@@ -45,7 +45,7 @@ func TestGetCandidatesShouldRemoveDuplication(t *testing.T) {
 	}
 
 	orig1, orig2, orig3 := matchConsecutive, matchFuzzy, matchAnywhere
-	var dummyMatcher = func(entries []*entry, args []string) []string {
+	var dummyMatcher = func(entries EntryList, args []string) []string {
 		return []string{"path1", "path2"}
 	}
 	matchConsecutive = dummyMatcher
@@ -55,7 +55,7 @@ func TestGetCandidatesShouldRemoveDuplication(t *testing.T) {
 		matchConsecutive, matchFuzzy, matchAnywhere = orig1, orig2, orig3
 	}()
 
-	entries := []*entry{{"path1", 10}}
+	entries := []*Entry{{Path: "path1", Score: 10}}
 	result := GetCandidates(entries, []string{"foo"}, 4)
 	expected := []string{"path1", "path2"}
 	assert.Equal(t, expected, result, "Incorrect candidates")
@@ -74,9 +74,9 @@ func TestGetCandidates(t *testing.T) {
 		"/tmp", "/foo/gxxbazabc",
 		"/tmp/abc", "/tmp/def",
 	}
-	var entries []*entry
+	var entries []*Entry
 	for _, p := range paths {
-		entries = append(entries, &entry{p, 1.0})
+		entries = append(entries, &Entry{Path: p, Score: 1.0})
 	}
 
 	result := GetCandidates(entries, []string{"foo", "bar"}, 2)
@@ -88,11 +88,11 @@ func TestGetCandidates(t *testing.T) {
 }
 
 func TestAnywhere(t *testing.T) {
-	entries := []*entry{
-		{"/foo/bar/baz", 10},
-		{"/foo/bazar", 10},
-		{"/tmp", 10},
-		{"/foo/gxxbazabc", 10},
+	entries := []*Entry{
+		{Path: "/foo/bar/baz", Score: 10},
+		{Path: "/foo/bazar", Score: 10},
+		{Path: "/tmp", Score: 10},
+		{Path: "/foo/gxxbazabc", Score: 10},
 	}
 	result := matchAnywhere(entries, []string{"foo", "baz"})
 	expected := []string{
@@ -104,12 +104,12 @@ func TestAnywhere(t *testing.T) {
 }
 
 func TestExactName(t *testing.T) {
-	entries := []*entry{
-		{"/app/open/tidb", 10},
-		{"/app/open/redis", 10},
-		{"/foo/redis-sdk/bazar", 10},
-		{"/tmp", 10},
-		{"/foo/tidb/gxxbazabc", 10},
+	entries := []*Entry{
+		{Path: "/app/open/tidb", Score: 10},
+		{Path: "/app/open/redis", Score: 10},
+		{Path: "/foo/redis-sdk/bazar", Score: 10},
+		{Path: "/tmp", Score: 10},
+		{Path: "/foo/tidb/gxxbazabc", Score: 10},
 	}
 	t.Run("Should returns empty result if the number of args is not exactly one", func(t *testing.T) {
 		result := matchExactName(entries, []string{"tidb", "baz"})
@@ -122,11 +122,11 @@ func TestExactName(t *testing.T) {
 }
 
 func TestFuzzy(t *testing.T) {
-	entries := []*entry{
-		{"/foo/bar/baz", 10},
-		{"/foo/bazar", 10},
-		{"/tmp", 10},
-		{"/foo/gxxbazabc", 10},
+	entries := []*Entry{
+		{Path: "/foo/bar/baz", Score: 10},
+		{Path: "/foo/bazar", Score: 10},
+		{Path: "/tmp", Score: 10},
+		{Path: "/foo/gxxbazabc", Score: 10},
 	}
 	result := matchFuzzy(entries, []string{"baz"})
 	expected := []string{
@@ -137,12 +137,12 @@ func TestFuzzy(t *testing.T) {
 }
 
 func TestConsecutive(t *testing.T) {
-	entries := []*entry{
-		{"/foo/bar/baz", 10},
-		{"/foo/baz/moo", 10},
-		{"/moo/foo/Baz", 10},
-		{"/foo/bazar", 10},
-		{"/foo/xxbaz", 10},
+	entries := []*Entry{
+		{Path: "/foo/bar/baz", Score: 10},
+		{Path: "/foo/baz/moo", Score: 10},
+		{Path: "/moo/foo/Baz", Score: 10},
+		{Path: "/foo/bazar", Score: 10},
+		{Path: "/foo/xxbaz", Score: 10},
 	}
 	result := matchConsecutive(entries, []string{"foo", "baz"})
 	expected := []string{
@@ -191,3 +191,176 @@ func TestCalculateDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestFinder(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	_, err := NewFinder([]string{MatchExactName, "bogus"})
+	assert.NotNil(t, err)
+	_, err = NewFinder(nil)
+	assert.NotNil(t, err)
+
+	entries := EntryList{
+		{Path: "/foo/bazar", Score: 10},
+		{Path: "/foo/baz", Score: 5},
+	}
+	assert.Equal(t, []string{"/foo/baz", "/foo/bazar"}, Finder{}.GetCandidates(entries, []string{"baz"}, 2))
+
+	finder, err := NewFinder([]string{MatchAnywhere})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/foo/bazar", "/foo/baz"}, finder.GetCandidates(entries, []string{"baz"}, 2))
+	assert.Equal(t, "/foo/bazar", finder.BestGuess(entries, []string{"baz"}))
+}
+
+func TestFind(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := EntryList{
+		{Path: "/foo/bazar", Score: 10},
+		{Path: "/foo/baz", Score: 5},
+		{Path: "/tmp/bxaxz", Score: 1},
+	}
+	result := Finder{}.Find(entries, []string{"baz"}, 3)
+	expected := []Candidate{
+		{"/foo/baz", 5, MatchExactName},
+		{"/foo/bazar", 10, MatchConsecutive},
+		{"/tmp/bxaxz", 1, MatchFuzzy},
+	}
+	assert.Equal(t, expected, result)
+}
+
+func TestFindPinnedPathFirst(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return p != "/gone"
+	}
+
+	entries := EntryList{
+		{Path: "/foo/infra", Score: 10},
+		{Path: "/src/infrastructure", Score: 5},
+	}
+	finder := Finder{}.WithPins(Pins{"infra": "/src/infrastructure", "old": "/gone"})
+	result := finder.Find(entries, []string{"infra"}, 3)
+	expected := []Candidate{
+		{"/src/infrastructure", 5, MatchPin},
+		{"/foo/infra", 10, MatchExactName},
+	}
+	assert.Equal(t, expected, result)
+	assert.Equal(t, "/src/infrastructure", finder.BestGuess(entries, []string{"infra"}))
+
+	// Pins only apply to a single keyword, and to existing paths
+	assert.Equal(t, "/foo/infra", finder.BestGuess(entries, []string{"foo", "infra"}))
+	assert.Equal(t, ".", finder.BestGuess(entries, []string{"old"}))
+
+	// Keywords being completed match pins by prefix, the pin of the keyword itself first
+	finder = Finder{}.WithPins(Pins{"inf": "/foo/infra", "infra": "/src/infrastructure", "in": "/gone"}).Completing()
+	assert.Equal(t, []string{"/src/infrastructure", "/foo/infra"}, finder.GetCandidates(entries, []string{"infr"}, 3))
+	assert.Equal(t, []string{"/foo/infra", "/src/infrastructure"}, finder.GetCandidates(entries, []string{"inf"}, 3))
+}
+
+func TestExactNameBeatsPinPrefix(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(string) bool { return true }
+
+	entries := EntryList{
+		{Path: "/foo/in", Score: 10},
+		{Path: "/src/infrastructure", Score: 5},
+	}
+	finder := Finder{}.WithPins(Pins{"infra": "/src/infrastructure"})
+	assert.Equal(t, "/foo/in", finder.BestGuess(entries, []string{"in"}))
+	result := finder.Find(entries, []string{"in"}, 3)
+	assert.Equal(t, Candidate{"/foo/in", 10, MatchExactName}, result[0])
+
+	// Only completion offers the pin of a keyword the argument starts
+	completions := finder.Completing().GetCandidates(entries, []string{"in"}, 3)
+	assert.Equal(t, []string{"/src/infrastructure", "/foo/in"}, completions)
+}
+
+func TestFinderWithScope(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := EntryList{
+		{Path: "/src/Web", Score: 40},
+		{Path: "/src/app/web", Score: 30},
+		{Path: "/src/app/web/static", Score: 25},
+		{Path: "/src/app-web", Score: 20},
+		{Path: "/src", Score: 10},
+		{Path: "/src/app", Score: 5},
+		{Path: "/", Score: 1},
+	}
+	cases := []struct {
+		scope    string
+		base     string
+		args     []string
+		expected []string
+	}{
+		{ScopeChild, "/src/app", []string{"web"}, []string{"/src/app/web", "/src/app/web/static"}},
+		{ScopeChild, "/src/app", []string{"WEB", "static"}, []string{"/src/app/web/static"}},
+		{ScopeChild, "/", []string{"static"}, []string{"/src/app/web/static"}},
+		{ScopeParent, "/src/app/web/static", []string{"src"}, []string{"/src", "/src/app/web", "/src/app"}},
+		{ScopeParent, "/src/app/web/static", []string{"web"}, []string{"/src/app/web"}},
+		{ScopeSibling, "/src/app", []string{"web"}, []string{"/src/Web", "/src/app-web"}},
+		{ScopeSibling, "/src/app", []string{"app"}, []string{"/src/app-web"}},
+	}
+	for _, c := range cases {
+		finder, err := Finder{}.WithScope(c.scope, c.base)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, finder.GetCandidates(entries, c.args, 9), "%s of %s", c.scope, c.base)
+	}
+
+	// Pins out of scope are ignored
+	finder, err := Finder{}.WithPins(Pins{"web": "/src/Web"}).WithScope(ScopeChild, "/src/app")
+	assert.Nil(t, err)
+	assert.Equal(t, "/src/app/web", finder.BestGuess(entries, []string{"web"}))
+
+	_, err = Finder{}.WithScope("cousin", "/src")
+	assert.NotNil(t, err)
+}
+
+func TestFinderWithScopeWithoutKeywords(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := EntryList{
+		{Path: "/src/Web", Score: 40},
+		{Path: "/src/app/web", Score: 30},
+		{Path: "/src", Score: 10},
+		{Path: "/src/app", Score: 5},
+	}
+	cases := []struct {
+		scope    string
+		base     string
+		expected string
+	}{
+		{ScopeChild, "/src/app", "/src/app/web"},
+		{ScopeParent, "/src/app/web", "/src"},
+		{ScopeSibling, "/src/app", "/src/Web"},
+		{ScopeChild, "/src/app/web", "."},
+	}
+	for _, ranking := range []string{RankingPriority, RankingCombined} {
+		for _, c := range cases {
+			finder, err := Finder{}.WithRanking(ranking, nil)
+			assert.Nil(t, err)
+			finder, err = finder.WithScope(c.scope, c.base)
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, finder.BestGuess(entries, nil), "%s of %s with %s ranking", c.scope, c.base, ranking)
+		}
+	}
+}
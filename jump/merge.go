@@ -0,0 +1,104 @@
+package jump
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Rewrite replaces the leading directories Old of paths with New,
+// like "/home/alice" with "/Users/alice".
+type Rewrite struct {
+	Old string
+	New string
+}
+
+// ParseRewrite parses a rewrite given as "old=new".
+func ParseRewrite(s string) (Rewrite, error) {
+	i := strings.LastIndexByte(s, '=')
+	if i <= 0 || i == len(s)-1 {
+		return Rewrite{}, fmt.Errorf("invalid rewrite, expected old=new: %q", s)
+	}
+	return NewRewrite(s[:i], s[i+1:]), nil
+}
+
+// NewRewrite creates a rewrite of the prefix old to new.
+func NewRewrite(old, new string) Rewrite {
+	return Rewrite{Old: trimSeparator(old), New: trimSeparator(new)}
+}
+
+func trimSeparator(path string) string {
+	if path == string(os.PathSeparator) {
+		return path
+	}
+	return strings.TrimSuffix(path, string(os.PathSeparator))
+}
+
+// Apply rewrites path if it is Old or a path under Old.
+// Only whole directory names match, so "/src/app" doesn't rewrite "/src/apps".
+func (r Rewrite) Apply(path string) (string, bool) {
+	if path == r.Old {
+		return r.New, true
+	}
+	prefix := r.Old
+	if !strings.HasSuffix(prefix, string(os.PathSeparator)) {
+		prefix += string(os.PathSeparator)
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return path, false
+	}
+	return filepath.Join(r.New, path[len(prefix):]), true
+}
+
+// rewritePath applies the first of rewrites that matches path.
+func rewritePath(path string, rewrites []Rewrite) string {
+	for _, r := range rewrites {
+		if p, ok := r.Apply(path); ok {
+			return p
+		}
+	}
+	return path
+}
+
+// mergeEntry combines other into e, which are entries of the same path
+// from different data files. The higher score is kept.
+func mergeEntry(e *Entry, other *Entry) {
+	e.Score = math.Max(e.Score, other.Score)
+	mergeMetadata(e, other)
+}
+
+// Merge combines the entries of another data file, for example one of another
+// machine, into the data file. Paths are rewritten first, and the ones that
+// don't exist on this machine or are excluded are skipped.
+//
+// For paths in both data files, the higher score and the latest visit are kept,
+// and the visits are summed.
+func (s Store) Merge(other EntryList, rewrites []Rewrite) (ImportStats, error) {
+	var stats ImportStats
+	err := s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		for _, o := range other {
+			path := rewritePath(o.Path, rewrites)
+			if !isValidPath(path) || s.excludes.Match(path) {
+				stats.Skipped++
+				continue
+			}
+			if entries.find(path) == nil {
+				stats.Added++
+			} else {
+				stats.Merged++
+			}
+			var ent *Entry
+			entries, ent = entries.add(path)
+			mergeEntry(ent, o)
+		}
+		entries.sortByRank(s.scorer, now())
+		return s.saveEntries(entries, rejected)
+	})
+	return stats, err
+}
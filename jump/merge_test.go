@@ -0,0 +1,97 @@
+package jump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRewrite(t *testing.T) {
+	r, err := ParseRewrite("/home/alice=/Users/alice/")
+	assert.Nil(t, err)
+	assert.Equal(t, Rewrite{Old: "/home/alice", New: "/Users/alice"}, r)
+
+	for _, s := range []string{"", "/home/alice", "=/Users/alice", "/home/alice="} {
+		_, err := ParseRewrite(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestRewriteApply(t *testing.T) {
+	r := NewRewrite("/home/alice", "/Users/alice")
+	cases := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"/home/alice", "/Users/alice", true},
+		{"/home/alice/src/app", "/Users/alice/src/app", true},
+		{"/home/alice2", "/home/alice2", false},
+		{"/home/bob", "/home/bob", false},
+	}
+	for _, c := range cases {
+		path, ok := r.Apply(c.path)
+		assert.Equal(t, c.expected, path)
+		assert.Equal(t, c.ok, ok)
+	}
+
+	path, ok := NewRewrite("/", "/mnt/old").Apply("/src")
+	assert.True(t, ok)
+	assert.Equal(t, "/mnt/old/src", path)
+}
+
+func TestMerge(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return p != "/Users/alice/gone"
+	}
+
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	early := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := NewStore(filepath.Join(dir, "data.txt"))
+	local := EntryList{
+		{Path: "/Users/alice/src", Score: 30, Visits: 3, LastVisit: late, FirstSeen: late},
+		{Path: "/Users/alice/docs", Score: 50, Visits: 5, LastVisit: early, FirstSeen: early},
+	}
+	assert.Nil(t, store.saveEntries(local, nil))
+
+	other := EntryList{
+		{Path: "/home/alice/src", Score: 40, Visits: 4, LastVisit: early, FirstSeen: early},
+		{Path: "/home/alice/docs", Score: 10, Visits: 1, LastVisit: late, FirstSeen: late},
+		{Path: "/home/alice/music", Score: 20, Visits: 2, LastVisit: late, FirstSeen: late},
+		{Path: "/home/alice/gone", Score: 90, Visits: 9, LastVisit: late, FirstSeen: late},
+	}
+	stats, err := store.Merge(other, []Rewrite{NewRewrite("/home/alice", "/Users/alice")})
+	assert.Nil(t, err)
+	assert.Equal(t, ImportStats{Added: 1, Merged: 2, Skipped: 1}, stats)
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(entries))
+
+	src := entries.find("/Users/alice/src")
+	assert.Equal(t, 40.0, src.Score)
+	assert.Equal(t, 7, src.Visits)
+	assert.True(t, src.LastVisit.Equal(late))
+	assert.True(t, src.FirstSeen.Equal(early))
+
+	docs := entries.find("/Users/alice/docs")
+	assert.Equal(t, 50.0, docs.Score)
+	assert.Equal(t, 6, docs.Visits)
+	assert.True(t, docs.LastVisit.Equal(late))
+	assert.True(t, docs.FirstSeen.Equal(early))
+
+	music := entries.find("/Users/alice/music")
+	assert.Equal(t, 20.0, music.Score)
+	assert.Equal(t, 2, music.Visits)
+}
@@ -0,0 +1,142 @@
+package jump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// MatchPin is the matcher name of candidates found through pins.
+// Pins are always consulted before the other matchers.
+const MatchPin = "pin"
+
+// Pins maps keywords to the paths they always jump to.
+type Pins map[string]string
+
+// Keywords returns the pinned keywords in alphabetical order.
+func (p Pins) Keywords() []string {
+	keywords := make([]string, 0, len(p))
+	for k := range p {
+		keywords = append(keywords, k)
+	}
+	sort.Strings(keywords)
+	return keywords
+}
+
+// lookup returns the path pinned to the only argument of a query.
+// With prefix, it is followed by the paths pinned to the keywords starting with
+// the argument, in their alphabetical order, so that pins come first when
+// a keyword is completed.
+func (p Pins) lookup(args []string, prefix bool) []string {
+	if len(args) != 1 {
+		return nil
+	}
+	var paths []string
+	if path, ok := p[args[0]]; ok {
+		paths = append(paths, path)
+	}
+	if !prefix {
+		return paths
+	}
+	for _, k := range p.Keywords() {
+		if k != args[0] && strings.HasPrefix(k, args[0]) {
+			paths = append(paths, p[k])
+		}
+	}
+	return paths
+}
+
+func validateKeyword(keyword string) error {
+	if keyword == "" || strings.IndexFunc(keyword, func(r rune) bool {
+		return r == '\t' || r == '\n' || r == ' '
+	}) != -1 {
+		return fmt.Errorf("invalid keyword: %q", keyword)
+	}
+	return nil
+}
+
+// pinsPath is where the pins are kept, next to the data file.
+func (s Store) pinsPath() string {
+	return s.path + ".pins"
+}
+
+// ReadPins returns the pins of the data file.
+func (s Store) ReadPins() (Pins, error) {
+	pins := make(Pins)
+	file, err := os.Open(s.pinsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pins, nil
+		}
+		return pins, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		keyword, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			log.Printf("Failed to parse pin from line: %v", line)
+			continue
+		}
+		pins[keyword] = path
+	}
+	return pins, scanner.Err()
+}
+
+func (s Store) savePins(pins Pins) error {
+	return writeAtomically(s.pinsPath(), func(w io.Writer) error {
+		for _, k := range pins.Keywords() {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", k, pins[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Pin makes keyword always jump to pathToPin, which must be an existing directory.
+// An existing pin of keyword is replaced.
+func (s Store) Pin(keyword, pathToPin string) (string, error) {
+	if err := validateKeyword(keyword); err != nil {
+		return "", err
+	}
+	path, err := preprocessPath(pathToPin)
+	if err != nil {
+		return "", err
+	}
+	if !isValidPath(path) {
+		return "", fmt.Errorf("invalid path: %v", path)
+	}
+	return path, s.withLock(func() error {
+		pins, err := s.ReadPins()
+		if err != nil {
+			return err
+		}
+		pins[keyword] = path
+		return s.savePins(pins)
+	})
+}
+
+// Unpin removes the pin of keyword and returns the path it was pinned to.
+func (s Store) Unpin(keyword string) (string, error) {
+	var path string
+	err := s.withLock(func() error {
+		pins, err := s.ReadPins()
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if path, ok = pins[keyword]; !ok {
+			return fmt.Errorf("no pin: %v", keyword)
+		}
+		delete(pins, keyword)
+		return s.savePins(pins)
+	})
+	return path, err
+}
@@ -0,0 +1,64 @@
+package jump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPins(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	infra, web := filepath.Join(dir, "infra"), filepath.Join(dir, "web")
+	for _, p := range []string{infra, web} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+	store := NewStore(filepath.Join(dir, "testEntries"))
+
+	pins, err := store.ReadPins()
+	assert.Nil(t, err)
+	assert.Empty(t, pins)
+
+	path, err := store.Pin("infra", infra+"/")
+	assert.Nil(t, err)
+	assert.Equal(t, infra, path)
+	_, err = store.Pin("web", web)
+	assert.Nil(t, err)
+	_, err = store.Pin("x", web)
+	assert.Nil(t, err)
+	_, err = store.Pin("x", infra)
+	assert.Nil(t, err, "Pins can be replaced")
+
+	pins, err = store.ReadPins()
+	assert.Nil(t, err)
+	assert.Equal(t, Pins{"infra": infra, "web": web, "x": infra}, pins)
+	assert.Equal(t, []string{"infra", "web", "x"}, pins.Keywords())
+
+	path, err = store.Unpin("x")
+	assert.Nil(t, err)
+	assert.Equal(t, infra, path)
+	_, err = store.Unpin("x")
+	assert.NotNil(t, err)
+
+	_, err = store.Pin("missing", filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+	_, err = store.Pin("two words", web)
+	assert.NotNil(t, err)
+	_, err = store.Pin("", web)
+	assert.NotNil(t, err)
+
+	// Pins take precedence over matches
+	assert.Nil(t, store.AddPath(web))
+	path, err = store.GetNthCandidate([]string{"web"}, 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, web, path)
+	path, err = store.GetNthCandidate([]string{"infra"}, 1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, infra, path)
+}
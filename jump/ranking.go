@@ -0,0 +1,96 @@
+package jump
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Ways to rank candidates found by several matchers
+const (
+	// RankingPriority puts all candidates of a matcher before those of the next matcher.
+	RankingPriority = "priority"
+	// RankingCombined ranks candidates by their scores weighted by the matchers that found them.
+	RankingCombined = "combined"
+)
+
+// DefaultMatchWeights are the weights of the matchers for RankingCombined.
+// An exact name beats a consecutive match unless the latter has a third more score.
+var DefaultMatchWeights = map[string]float64{
+	MatchExactName:   4,
+	MatchConsecutive: 3,
+	MatchFuzzy:       2,
+	MatchAnywhere:    1,
+	MatchSubsequence: 2,
+}
+
+// WithRanking returns a copy of f ranking candidates the given way.
+// weights overrides DefaultMatchWeights for RankingCombined.
+func (f Finder) WithRanking(ranking string, weights map[string]float64) (Finder, error) {
+	switch ranking {
+	case RankingPriority, RankingCombined:
+	default:
+		return f, fmt.Errorf("unknown ranking: %q", ranking)
+	}
+	merged := make(map[string]float64, len(DefaultMatchWeights))
+	for name, w := range DefaultMatchWeights {
+		merged[name] = w
+	}
+	for name, w := range weights {
+		if _, ok := lookupMatcher(name); !ok {
+			return f, fmt.Errorf("unknown matcher: %q", name)
+		}
+		if w < 0 {
+			return f, fmt.Errorf("weight of matcher %s must not be negative: %v", name, w)
+		}
+		merged[name] = w
+	}
+	f.ranking = ranking
+	f.weights = merged
+	return f, nil
+}
+
+// WithScorer returns a copy of f that weights the ranks sc gives to entries
+// with RankingCombined. Without a scorer, the scores of entries are weighted.
+func (f Finder) WithScorer(sc Scorer) Finder {
+	f.scorer = sc
+	return f
+}
+
+// entryRanks maps the paths of entries to the values f ranks them by.
+func (f Finder) entryRanks(entries EntryList) map[string]float64 {
+	if f.scorer == nil {
+		return entryScores(entries)
+	}
+	t := now()
+	ranks := make(map[string]float64, len(entries))
+	for _, e := range entries {
+		ranks[e.Path] = f.scorer.Rank(e, t)
+	}
+	return ranks
+}
+
+// combined tells if f uses RankingCombined.
+func (f Finder) combined() bool {
+	return f.ranking == RankingCombined
+}
+
+// combinedScore is the rank of c with RankingCombined,
+// given the ranks of paths from entryRanks.
+// Pinned paths always come first.
+func (f Finder) combinedScore(c Candidate, ranks map[string]float64) float64 {
+	if c.Matcher == MatchPin {
+		return math.Inf(1)
+	}
+	return f.weights[c.Matcher] * ranks[c.Path]
+}
+
+// rank sorts candidates found in the order of the matchers the way f ranks them.
+func (f Finder) rank(candidates []Candidate, ranks map[string]float64) {
+	if !f.combined() {
+		return
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return f.combinedScore(candidates[i], ranks) > f.combinedScore(candidates[j], ranks)
+	})
+}
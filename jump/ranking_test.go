@@ -0,0 +1,159 @@
+package jump
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readRankingFixture(t testing.TB) EntryList {
+	entries, err := NewStore(filepath.Join("testdata", "ranking", "entries.txt")).ReadEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestRankings(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := readRankingFixture(t)
+	priority, err := Finder{}.WithRanking(RankingPriority, nil)
+	assert.Nil(t, err)
+	combined, err := Finder{}.WithRanking(RankingCombined, nil)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		query    string
+		priority string
+		combined string
+	}{
+		{"api", "/home/user/src/monorepo/services/api", "/home/user/src/monorepo/services/api"},
+		{"web", "/home/user/src/monorepo/web", "/home/user/src/monorepo/web"},
+		{"mono", "/home/user/src/monorepo", "/home/user/src/monorepo"},
+		// A barely used exact name only wins with the priority ranking
+		{"shonen", "/home/user/old/shonen", "/home/user/src/shonenjump"},
+		{"reports", "/home/user/reports", "/home/user/work/reports-2023"},
+	}
+	for _, c := range cases {
+		args := []string{c.query}
+		assert.Equal(t, c.priority, Finder{}.BestGuess(entries, args), "default of %s", c.query)
+		assert.Equal(t, c.priority, priority.BestGuess(entries, args), "priority of %s", c.query)
+		assert.Equal(t, c.combined, combined.BestGuess(entries, args), "combined of %s", c.query)
+	}
+
+	// Both rankings find the same candidates
+	args := []string{"shonen"}
+	assert.ElementsMatch(t, priority.GetCandidates(entries, args, 9), combined.GetCandidates(entries, args, 9))
+	assert.Equal(t, []string{"/home/user/src/shonenjump"}, combined.GetCandidates(entries, args, 1))
+}
+
+func TestCombinedRankingWeights(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := readRankingFixture(t)
+	finder, err := Finder{}.WithRanking(RankingCombined, map[string]float64{MatchExactName: 1000})
+	assert.Nil(t, err)
+	assert.Equal(t, "/home/user/old/shonen", finder.BestGuess(entries, []string{"shonen"}))
+
+	// Pins still come first
+	finder = finder.WithPins(Pins{"shonen": "/home/user/src/shonenjump"})
+	assert.Equal(t, "/home/user/src/shonenjump", finder.BestGuess(entries, []string{"shonen"}))
+
+	_, err = Finder{}.WithRanking("random", nil)
+	assert.NotNil(t, err)
+	_, err = Finder{}.WithRanking(RankingCombined, map[string]float64{"bogus": 1})
+	assert.NotNil(t, err)
+	_, err = Finder{}.WithRanking(RankingCombined, map[string]float64{MatchFuzzy: -1})
+	assert.NotNil(t, err)
+}
+
+func TestCombinedRankingWithScorer(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+	origNow := now
+	defer func() { now = origNow }()
+	t0 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+
+	// Frecency ranks the exact name visited a minute ago at 4 * 4, and the
+	// consecutive match visited a month ago at 30 / 4
+	entries := EntryList{
+		{Path: "/src/api-server", Score: 30, LastVisit: t0.Add(-30 * 24 * time.Hour)},
+		{Path: "/src/api", Score: 4, LastVisit: t0.Add(-time.Minute)},
+	}
+	finder, err := Finder{}.WithRanking(RankingCombined, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "/src/api-server", finder.BestGuess(entries, []string{"api"}))
+	finder = finder.WithScorer(FrecencyScorer{})
+	assert.Equal(t, "/src/api", finder.BestGuess(entries, []string{"api"}))
+}
+
+func TestExplainCombinedRanking(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	entries := readRankingFixture(t)
+	finder, err := Finder{}.WithRanking(RankingCombined, nil)
+	assert.Nil(t, err)
+	explanations := finder.Explain(entries, []string{"shonen"})
+	assert.Equal(t, "/home/user/old/shonen", explanations[0].Path)
+	assert.Equal(t, 2, explanations[0].Rank)
+	assert.Equal(t, "/home/user/src/shonenjump", explanations[1].Path)
+	assert.Equal(t, 1, explanations[1].Rank)
+	assert.Equal(t, "", explanations[1].OutrankedBy)
+}
+
+func generateManyEntries(n int) EntryList {
+	entries := make(EntryList, n)
+	for i := range entries {
+		entries[i] = &Entry{
+			Path:  fmt.Sprintf("/home/user/src/project%d/module%d", i%100, i),
+			Score: float64(n - i),
+		}
+	}
+	return entries
+}
+
+func benchmarkRanking(b *testing.B, ranking string) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+	entries := generateManyEntries(10000)
+	finder, err := Finder{}.WithRanking(ranking, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		finder.Find(entries, []string{"project4", "module"}, MaxCompleteOptions)
+	}
+}
+
+func BenchmarkFindPriorityRanking(b *testing.B) {
+	benchmarkRanking(b, RankingPriority)
+}
+
+func BenchmarkFindCombinedRanking(b *testing.B) {
+	benchmarkRanking(b, RankingCombined)
+}
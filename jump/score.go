@@ -0,0 +1,183 @@
+package jump
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Names of the available scoring strategies
+const (
+	ScoringClassic  = "classic"
+	ScoringFrecency = "frecency"
+)
+
+// Scorer decides how entries are scored when paths are visited
+// and how they are ranked in query results.
+// Use WithScorer to plug an implementation into a Store.
+type Scorer interface {
+	// OnVisit updates the score of an entry that has been visited with the given weight.
+	// It is also used to adjust scores manually, in which case weight may be negative.
+	OnVisit(e *Entry, weight float64, t time.Time)
+	// OnAge is called on all existing entries before a path is visited.
+	OnAge(entries EntryList, t time.Time)
+	// Rank returns the value entries are sorted by, in descending order.
+	Rank(e *Entry, t time.Time) float64
+}
+
+// Combiner can be implemented by a Scorer to combine the scores of two entries
+// of the same path, like when entries are moved onto an existing one.
+// The Scorers that don't implement it add the score of the other entry
+// to an entry as the weight of a visit.
+type Combiner interface {
+	// Combine adds score, the score of another entry of the same path, to e.
+	Combine(e *Entry, score float64)
+}
+
+// VisitConverter can be implemented by a Scorer to convert its scores to and from
+// the ranks of other jumpers, which count visits. Scores of the Scorers that
+// don't implement it are converted like those of ClassicScorer.
+type VisitConverter interface {
+	// FromVisits returns the score of an entry visited n times with DefaultWeight.
+	FromVisits(n float64) float64
+	// ToVisits is the inverse of FromVisits.
+	ToVisits(score float64) float64
+}
+
+var scorers = map[string]Scorer{
+	ScoringClassic:  ClassicScorer{},
+	ScoringFrecency: FrecencyScorer{},
+}
+
+// NewScorer returns one of the builtin scoring strategies by name.
+func NewScorer(name string) (Scorer, error) {
+	sc, ok := scorers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring: %q", name)
+	}
+	return sc, nil
+}
+
+// ClassicScorer implements the autojump rule: scores grow with the square root
+// of the sum of squared weights, and decay whenever another path is visited.
+type ClassicScorer struct {
+	// AgingRate is the part of their scores entries lose when another path is visited.
+	// Zero means the default of 10%.
+	AgingRate float64
+}
+
+func (ClassicScorer) OnVisit(e *Entry, weight float64, t time.Time) {
+	e.updateScore(weight)
+}
+
+func (sc ClassicScorer) OnAge(entries EntryList, t time.Time) {
+	rate := sc.AgingRate
+	if rate == 0 {
+		rate = DefaultAgingRate
+	}
+	entries.ageBy(rate)
+}
+
+func (ClassicScorer) Rank(e *Entry, t time.Time) float64 {
+	return e.Score
+}
+
+func (ClassicScorer) Combine(e *Entry, score float64) {
+	e.updateScore(score)
+}
+
+func (ClassicScorer) FromVisits(n float64) float64 {
+	return math.Sqrt(n) * DefaultWeight
+}
+
+func (ClassicScorer) ToVisits(score float64) float64 {
+	r := math.Max(score, 0) / DefaultWeight
+	return r * r
+}
+
+const (
+	// When the sum of all frecency scores exceeds this, they are scaled down.
+	maxFrecencyTotal = 10000.0
+)
+
+// FrecencyScorer implements the zoxide rule: scores count visits,
+// and are weighted by how long ago the last visit happened when ranked.
+type FrecencyScorer struct{}
+
+func (FrecencyScorer) OnVisit(e *Entry, weight float64, t time.Time) {
+	// A regular visit counts as one
+	e.Score = math.Max(e.Score+weight/DefaultWeight, 0)
+}
+
+func (FrecencyScorer) OnAge(entries EntryList, t time.Time) {
+	var total float64
+	for _, e := range entries {
+		total += e.Score
+	}
+	if total <= maxFrecencyTotal {
+		return
+	}
+	factor := 0.9 * maxFrecencyTotal / total
+	for _, e := range entries {
+		e.Score *= factor
+	}
+}
+
+func (FrecencyScorer) Combine(e *Entry, score float64) {
+	// Both scores count visits
+	e.Score += score
+}
+
+func (FrecencyScorer) FromVisits(n float64) float64 {
+	return n
+}
+
+func (FrecencyScorer) ToVisits(score float64) float64 {
+	return math.Max(score, 0)
+}
+
+// combine adds score, the score of another entry of the same path, to e.
+func combine(sc Scorer, e *Entry, score float64, t time.Time) {
+	if c, ok := sc.(Combiner); ok {
+		c.Combine(e, score)
+		return
+	}
+	sc.OnVisit(e, score, t)
+}
+
+// fromVisits returns the score of sc for n visits.
+func fromVisits(sc Scorer, n float64) float64 {
+	if c, ok := sc.(VisitConverter); ok {
+		return c.FromVisits(n)
+	}
+	return ClassicScorer{}.FromVisits(n)
+}
+
+// toVisits returns the number of visits a score of sc stands for.
+func toVisits(sc Scorer, score float64) float64 {
+	if c, ok := sc.(VisitConverter); ok {
+		return c.ToVisits(score)
+	}
+	return ClassicScorer{}.ToVisits(score)
+}
+
+// convertScore converts a score of from to the score of to for as many visits.
+func convertScore(score float64, from, to Scorer) float64 {
+	return fromVisits(to, toVisits(from, score))
+}
+
+func (FrecencyScorer) Rank(e *Entry, t time.Time) float64 {
+	elapsed := t.Sub(e.LastVisit)
+	switch {
+	case e.LastVisit.IsZero():
+		return e.Score / 4
+	case elapsed < time.Hour:
+		return e.Score * 4
+	case elapsed < 24*time.Hour:
+		return e.Score * 2
+	case elapsed < 7*24*time.Hour:
+		return e.Score / 2
+	default:
+		return e.Score / 4
+	}
+}
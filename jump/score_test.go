@@ -0,0 +1,185 @@
+package jump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassicScorer(t *testing.T) {
+	sc := ClassicScorer{}
+	e := &Entry{Path: "/etc/init", Score: 0}
+	sc.OnVisit(e, 10, time.Now())
+	assert.Equal(t, float64(10), e.Score)
+
+	entries := EntryList{e}
+	sc.OnAge(entries, time.Now())
+	assert.Equal(t, float64(9), e.Score)
+	assert.Equal(t, e.Score, sc.Rank(e, time.Now()))
+
+	sc = ClassicScorer{AgingRate: 0.5}
+	sc.OnAge(entries, time.Now())
+	assert.Equal(t, float64(4), e.Score)
+
+	e.Score = 3
+	sc.Combine(e, 4)
+	assert.Equal(t, float64(5), e.Score)
+}
+
+func TestFrecencyScorer(t *testing.T) {
+	sc := FrecencyScorer{}
+	t0 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should count visits", func(t *testing.T) {
+		e := &Entry{Path: "/etc/init"}
+		sc.OnVisit(e, DefaultWeight, t0)
+		sc.OnVisit(e, DefaultWeight, t0)
+		assert.Equal(t, float64(2), e.Score)
+	})
+
+	t.Run("Should sum visits when combining entries", func(t *testing.T) {
+		e := &Entry{Path: "/etc/init", Score: 3}
+		sc.Combine(e, 4)
+		assert.Equal(t, float64(7), e.Score)
+	})
+
+	t.Run("Should weight scores by time of last visit", func(t *testing.T) {
+		e := &Entry{Path: "/etc/init", Score: 8, LastVisit: t0}
+		cases := []struct {
+			elapsed time.Duration
+			rank    float64
+		}{
+			{time.Minute, 32},
+			{2 * time.Hour, 16},
+			{3 * 24 * time.Hour, 4},
+			{30 * 24 * time.Hour, 2},
+		}
+		for _, c := range cases {
+			assert.Equal(t, c.rank, sc.Rank(e, t0.Add(c.elapsed)))
+		}
+		assert.Equal(t, float64(2), sc.Rank(&Entry{Score: 8}, t0))
+	})
+
+	t.Run("Should only age entries when the total is too high", func(t *testing.T) {
+		entries := EntryList{{Path: "/a", Score: 1000}, {Path: "/b", Score: 3000}}
+		sc.OnAge(entries, t0)
+		assert.Equal(t, float64(1000), entries[0].Score)
+
+		entries = append(entries, &Entry{Path: "/c", Score: 16000})
+		sc.OnAge(entries, t0)
+		var total float64
+		for _, e := range entries {
+			total += e.Score
+		}
+		assert.InDelta(t, 0.9*maxFrecencyTotal, total, 0.01)
+		assert.InDelta(t, 450, entries[0].Score, 0.01)
+	})
+}
+
+func TestWithScoring(t *testing.T) {
+	_, err := WithScoring("unknown")
+	assert.NotNil(t, err)
+
+	opt, err := WithScoring(ScoringFrecency)
+	assert.Nil(t, err)
+	store := NewStore("", opt)
+	assert.Equal(t, FrecencyScorer{}, store.scorer)
+}
+
+func TestFrecencyRanking(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	origNow := now
+	defer func() { now = origNow }()
+	t0 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return t0 }
+
+	old, recent := filepath.Join(dir, "old"), filepath.Join(dir, "recent")
+	for _, p := range []string{old, recent} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+
+	opt, err := WithScoring(ScoringFrecency)
+	assert.Nil(t, err)
+	store := NewStore(filepath.Join(dir, "testEntries"), opt)
+	path, err := store.GetTopPath(".")
+	assert.Nil(t, err)
+	assert.Equal(t, ".", path)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, store.AddPath(old))
+	}
+	now = func() time.Time { return t0.Add(30 * 24 * time.Hour) }
+	assert.Nil(t, store.AddPath(recent))
+
+	// The old path has more visits, but they happened a month ago
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, recent, entries[0].Path)
+	assert.Equal(t, old, entries[1].Path)
+
+	path, err = store.GetTopPath(".")
+	assert.Nil(t, err)
+	assert.Equal(t, recent, path)
+}
+
+// visitCounter ranks entries by the number of visits only
+type visitCounter struct{}
+
+func (visitCounter) OnVisit(e *Entry, weight float64, t time.Time) {}
+
+func (visitCounter) OnAge(entries EntryList, t time.Time) {}
+
+func (visitCounter) Rank(e *Entry, t time.Time) float64 {
+	return float64(e.Visits)
+}
+
+func TestCombine(t *testing.T) {
+	e := &Entry{Path: "/etc/init", Score: 3}
+	combine(FrecencyScorer{}, e, 4, time.Now())
+	assert.Equal(t, float64(7), e.Score)
+
+	// Scorers that aren't Combiners, like this one hiding the Combine method
+	// of ClassicScorer, add the score as the weight of a visit
+	e = &Entry{Path: "/etc/init", Score: 3}
+	combine(struct{ Scorer }{ClassicScorer{}}, e, 4, time.Now())
+	assert.Equal(t, float64(5), e.Score)
+}
+
+func TestConvertScore(t *testing.T) {
+	assert.Equal(t, float64(40), convertScore(4, FrecencyScorer{}, ClassicScorer{}))
+	assert.Equal(t, float64(4), convertScore(40, ClassicScorer{}, FrecencyScorer{}))
+	// Scorers that aren't VisitConverters convert like the classic scoring
+	assert.Equal(t, float64(40), fromVisits(visitCounter{}, 4))
+	assert.Equal(t, float64(4), toVisits(visitCounter{}, 40))
+}
+
+func TestWithScorer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+
+	store := NewStore(filepath.Join(dir, "testEntries"), WithScorer(visitCounter{}))
+	for _, p := range []string{a, a, b} {
+		assert.Nil(t, store.AddPath(p))
+	}
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, a, entries[0].Path)
+	assert.Equal(t, float64(0), entries[0].Score)
+	assert.Equal(t, b, entries[1].Path)
+}
@@ -2,125 +2,484 @@ package jump
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// Store keeps entries in a data file.
+// It is safe to use from multiple processes at the same time.
 type Store struct {
-	path string
+	path        string
+	lockTimeout time.Duration
+	scorer      Scorer
+	excludes    Excludes
+	finder      Finder
+	weight      float64
+	// journalLimit is the size of the journal past which AddPath folds it into the data file
+	journalLimit int64
 }
 
-func NewStore(dataPath string) Store {
-	return Store{
-		path: dataPath,
+// Option configures a Store.
+type Option func(*Store)
+
+// WithScorer makes the Store score and rank entries with sc.
+func WithScorer(sc Scorer) Option {
+	return func(s *Store) {
+		s.scorer = sc
 	}
 }
 
-func (s Store) AddPath(pathToAdd string) error {
-	path, err := preprocessPath(pathToAdd)
+// WithFinder makes the Store look up candidates with f.
+func WithFinder(f Finder) Option {
+	return func(s *Store) {
+		s.finder = f
+	}
+}
+
+// WithWeight changes the weight of a visit to a path, which is DefaultWeight by default.
+func WithWeight(weight float64) Option {
+	return func(s *Store) {
+		s.weight = weight
+	}
+}
+
+// WithExcludes keeps the Store from recording paths matching excludes.
+func WithExcludes(excludes Excludes) Option {
+	return func(s *Store) {
+		s.excludes = excludes
+	}
+}
+
+// WithScoring selects one of the builtin scoring strategies by name.
+func WithScoring(name string) (Option, error) {
+	sc, err := NewScorer(name)
 	if err != nil {
+		return nil, err
+	}
+	return WithScorer(sc), nil
+}
+
+// NewStore creates a Store keeping its entries at dataPath.
+func NewStore(dataPath string, opts ...Option) Store {
+	s := Store{
+		path:         dataPath,
+		lockTimeout:  defaultLockTimeout,
+		scorer:       ClassicScorer{},
+		weight:       DefaultWeight,
+		journalLimit: defaultJournalLimit,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	// The finder ranks entries the way the Store sorts them
+	s.finder = s.finder.WithScorer(s.scorer)
+	return s
+}
+
+// AddPath records a visit to pathToAdd, which must be an existing directory.
+// Excluded paths are ignored.
+// The visit is appended to the journal, which is folded into the data file
+// once it grows past its limit or when the entries are read.
+func (s Store) AddPath(pathToAdd string) error {
+	path, ok, err := s.pathToAdd(pathToAdd)
+	if err != nil || !ok {
 		return err
 	}
+	return s.withLock(func() error {
+		size, err := s.appendJournal(journalVisit{path, s.weight, now()})
+		if err != nil || size <= s.journalLimit {
+			return err
+		}
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		return s.saveEntries(entries, rejected)
+	})
+}
+
+// pathToAdd cleans up the path of a visit, ok is false if the path is excluded.
+func (s Store) pathToAdd(pathToAdd string) (path string, ok bool, err error) {
+	path, err = preprocessPath(pathToAdd)
+	if err != nil {
+		return "", false, err
+	}
 	if !isValidPath(path) {
-		return fmt.Errorf("invalid path: %v", path)
+		return "", false, fmt.Errorf("invalid path: %v", path)
+	}
+	return path, !s.excludes.Match(path), nil
+}
+
+// visit records a visit to path at t, aging the other entries.
+func (s Store) visit(entries EntryList, path string, t time.Time) EntryList {
+	s.scorer.OnAge(entries, t)
+	entries, ent := entries.add(path)
+	s.scorer.OnVisit(ent, s.weight, t)
+	ent.recordVisit(t)
+	entries.sortByRank(s.scorer, t)
+	return entries
+}
+
+// Adjust changes the score of pathToAdjust by weight without aging other entries.
+// A positive weight counts as a visit, a negative one lowers the score.
+// Only paths already in the data file can be lowered.
+func (s Store) Adjust(pathToAdjust string, weight float64) (*Entry, error) {
+	path, err := preprocessPath(pathToAdjust)
+	if err != nil {
+		return nil, err
 	}
-	oldEntries, err := s.ReadEntries()
+	var ent *Entry
+	err = s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		if weight < 0 && entries.find(path) == nil {
+			return fmt.Errorf("path not in database: %v", path)
+		}
+		if !isValidPath(path) {
+			return fmt.Errorf("invalid path: %v", path)
+		}
+		t := now()
+		entries, ent = entries.add(path)
+		s.scorer.OnVisit(ent, weight, t)
+		if weight > 0 {
+			ent.recordVisit(t)
+		}
+		entries.sortByRank(s.scorer, t)
+		return s.saveEntries(entries, rejected)
+	})
+	return ent, err
+}
+
+// withLock runs f while holding the lock on the data file, so that the
+// read-modify-write cycles of concurrent processes don't overwrite each
+// other's changes.
+func (s Store) withLock(f func() error) error {
+	lock, err := acquireLock(s.path+".lock", s.lockTimeout)
 	if err != nil {
 		return err
 	}
-	oldEntries.Age()
-	newEntries := oldEntries.Update(path, defaultWeight)
-	return s.saveEntries(newEntries)
+	defer lock.Close()
+	return f()
 }
 
+// ReadEntries returns all entries sorted by rank.
+// Lines of the data file that can't be parsed are skipped.
+// Visits waiting in the journal are folded into the data file first.
 func (s Store) ReadEntries() (EntryList, error) {
+	if !s.hasJournal() {
+		entries, _, err := s.readDataFile()
+		return entries, err
+	}
 	var entries EntryList
-	file, err := os.Open(s.path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return entries, nil
+	err := s.withLock(func() error {
+		var rejected []string
+		var err error
+		if entries, rejected, err = s.readEntries(); err != nil {
+			return err
 		}
-		return entries, err
+		return s.saveEntries(entries, rejected)
+	})
+	return entries, err
+}
+
+// readEntries returns the entries of the data file with the visits of the journal applied,
+// and the lines of the data file that can't be parsed, to be passed on to saveEntries.
+// It has to be called with the lock held.
+func (s Store) readEntries() (EntryList, []string, error) {
+	entries, rejected, err := s.readDataFile()
+	if err != nil {
+		return nil, nil, err
 	}
-	defer file.Close()
+	visits, err := s.readJournal()
+	if err != nil || len(visits) == 0 {
+		return entries, rejected, err
+	}
+	return s.replay(entries, visits), rejected, nil
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		entry, err := parseEntry(line)
+// readDataFile returns the entries of the data file and the lines that can't be parsed.
+func (s Store) readDataFile() (EntryList, []string, error) {
+	var entries EntryList
+	var rejected []string
+	err := s.scanDataFile(func(n int, line string, ent Entry, err error) bool {
 		if err != nil {
-			log.Printf("Failed to parse score from line: %v", line)
-			continue
+			log.Printf("Skipping line %d of the data file: %v", n, err)
+			rejected = append(rejected, line)
+		} else {
+			entries = append(entries, &ent)
 		}
-		entries = append(entries, &entry)
-	}
+		return true
+	})
 	if entries != nil {
-		entries.Sort()
+		entries.sortByRank(s.scorer, now())
 	}
-	return entries, nil
+	return entries, rejected, err
 }
 
-func (s Store) topEntry() (entry, error) {
-	var ent entry
+func (s Store) topEntry() (Entry, error) {
+	var top Entry
+	err := s.scanDataFile(func(n int, line string, ent Entry, err error) bool {
+		if err != nil {
+			log.Printf("Skipping line %d of the data file: %v", n, err)
+			return true
+		}
+		top = ent
+		return false
+	})
+	return top, err
+}
 
+// scanDataFile calls f with the number, the text and the parsed entry of every
+// line of the data file, until f returns false.
+// Blank lines are skipped, and so is the data file if it doesn't exist.
+func (s Store) scanDataFile(f func(n int, line string, ent Entry, err error) bool) error {
 	file, err := os.Open(s.path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ent, nil
+			return nil
 		}
-		return ent, err
+		return err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		ent, err := parseEntry(line)
-		if err != nil {
-			log.Printf("Failed to parse score from line: %v", line)
-			continue
+	// Unlike bufio.Scanner, bufio.Reader has no limit on the length of lines,
+	// so a very long line doesn't end the reading of the file early.
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		eof := err == io.EOF
+		line = strings.TrimSuffix(line, "\n")
+		if strings.TrimSpace(line) != "" {
+			ent, err := ParseEntry(line)
+			if !f(n, line, ent, err) {
+				return nil
+			}
+		}
+		if eof {
+			return nil
 		}
-		return ent, nil
 	}
-	return ent, nil
 }
 
+// Cleanup removes entries of paths that no longer exist.
 func (s Store) Cleanup() error {
-	entries, err := s.ReadEntries()
+	return s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		entries, changed := clearNotExistDirs(entries)
+		if changed {
+			return s.saveEntries(entries, rejected)
+		}
+		return nil
+	})
+}
+
+// PurgeExcluded removes the entries of excluded paths and returns them.
+func (s Store) PurgeExcluded() (EntryList, error) {
+	var removed EntryList
+	err := s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		var paths []string
+		for _, e := range entries {
+			if s.excludes.Match(e.Path) {
+				paths = append(paths, e.Path)
+			}
+		}
+		entries, removed = entries.remove(paths)
+		if len(removed) == 0 {
+			return nil
+		}
+		return s.saveEntries(entries, rejected)
+	})
+	return removed, err
+}
+
+// Remove deletes entries from the data file and returns them.
+// If args is a single path, like "." or "/tmp/foo", only the entry of that path is removed.
+// Otherwise args is a query and the entries it matches by exact name or consecutive
+// keywords are removed. The looser matchers are left out, as they would match most
+// of the data file.
+// If confirm isn't nil, it is called with the entries about to be removed,
+// and nothing is removed unless it returns true.
+func (s Store) Remove(args []string, confirm func(EntryList) bool) (EntryList, error) {
+	var removed EntryList
+	err := s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		var paths []string
+		if len(args) == 1 && isPathArg(args[0]) {
+			path, err := preprocessPath(args[0])
+			if err != nil {
+				return err
+			}
+			paths = []string{path}
+		} else {
+			paths = s.finder.strict().GetCandidates(entries, args, len(entries))
+		}
+		entries, removed = entries.remove(paths)
+		if len(removed) == 0 {
+			return nil
+		}
+		if confirm != nil && !confirm(removed) {
+			removed = nil
+			return nil
+		}
+		return s.saveEntries(entries, rejected)
+	})
+	return removed, err
+}
+
+// Move rewrites the paths of the entries under oldPrefix, and of oldPrefix itself,
+// to be under newPrefix, so that renamed directories keep their scores.
+// A moved entry is combined with the entry already recorded for its new path
+// the way visits add to a score, and their visits are summed.
+// It returns the number of moved entries.
+func (s Store) Move(oldPrefix, newPrefix string) (int, error) {
+	oldPath, err := preprocessPath(oldPrefix)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	entries, changed := clearNotExistDirs(entries)
-	if changed {
-		return s.saveEntries(entries)
+	newPath, err := preprocessPath(newPrefix)
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	if oldPath == newPath {
+		return 0, fmt.Errorf("same path: %v", newPath)
+	}
+	if !isValidPath(newPath) {
+		return 0, fmt.Errorf("invalid path: %v", newPath)
+	}
+	rewrite := NewRewrite(oldPath, newPath)
+	var moved int
+	err = s.withLock(func() error {
+		entries, rejected, err := s.readEntries()
+		if err != nil {
+			return err
+		}
+		var kept, movedEntries EntryList
+		for _, e := range entries {
+			if path, ok := rewrite.Apply(e.Path); ok {
+				e.Path = path
+				movedEntries = append(movedEntries, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		if len(movedEntries) == 0 {
+			return nil
+		}
+		t := now()
+		for _, e := range movedEntries {
+			var ent *Entry
+			kept, ent = kept.add(e.Path)
+			combine(s.scorer, ent, e.Score, t)
+			mergeMetadata(ent, e)
+		}
+		moved = len(movedEntries)
+		kept.sortByRank(s.scorer, t)
+		return s.saveEntries(kept, rejected)
+	})
+	return moved, err
 }
 
+// GetNthCandidate returns the index-th (1-based) candidate matching args,
+// or defaultPath if there are not enough candidates.
 func (s Store) GetNthCandidate(args []string, index int, defaultPath string) (string, error) {
 	entries, err := s.ReadEntries()
 	if err != nil {
 		return "", err
 	}
-	candidates := GetCandidates(entries, args, index)
+	pins, err := s.ReadPins()
+	if err != nil {
+		return "", err
+	}
+	candidates := s.finder.WithPins(pins).GetCandidates(entries, args, index)
 	if len(candidates) == index {
 		return candidates[index-1], nil
 	}
 	return defaultPath, nil
 }
 
+// GetTopPath returns the highest ranked path, or defaultPath if there are no entries.
 func (s Store) GetTopPath(defaultPath string) (string, error) {
+	if _, ok := s.scorer.(ClassicScorer); !ok || s.hasJournal() {
+		// Rankings of other scorers change as time goes by,
+		// so the order of the data file can't be relied on,
+		// and neither can it before the journal is folded into it.
+		entries, err := s.ReadEntries()
+		if err != nil {
+			return "", err
+		}
+		if len(entries) == 0 {
+			return defaultPath, nil
+		}
+		return entries[0].Path, nil
+	}
 	ent, err := s.topEntry()
 	if err != nil {
 		return "", err
 	}
-	return ent.val, nil
+	if ent.Path == "" {
+		return defaultPath, nil
+	}
+	return ent.Path, nil
+}
+
+// saveEntries replaces the data file with entries and clears the journal,
+// so entries have to be read with readEntries, which applies the journal.
+// rejected are the lines of the data file that can't be parsed, as returned by readEntries,
+// they are moved to the quarantine file once the data file is replaced.
+// The journal is marked as folded before that, in case it can't be cleared.
+func (s Store) saveEntries(entries EntryList, rejected []string) error {
+	folding := s.hasJournal()
+	err := writeAtomically(s.path, func(w io.Writer) error {
+		h := sha256.New()
+		w = io.MultiWriter(w, h)
+		for _, e := range entries {
+			if !isValidPath(e.Path) {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, e); err != nil {
+				return err
+			}
+		}
+		if folding {
+			return s.markFolded(hex.EncodeToString(h.Sum(nil)))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.quarantine(rejected); err != nil {
+		return err
+	}
+	return s.clearJournal()
 }
 
-func (s Store) saveEntries(entries EntryList) error {
-	folder := filepath.Dir(s.path)
+// writeAtomically replaces the file at path with what write writes,
+// so that readers never see a partially written file.
+func writeAtomically(path string, write func(io.Writer) error) error {
+	folder := filepath.Dir(path)
 	if err := os.MkdirAll(folder, 0740); err != nil {
 		return err
 	}
@@ -132,15 +491,12 @@ func (s Store) saveEntries(entries EntryList) error {
 	defer os.Remove(tempfile.Name())
 
 	writer := bufio.NewWriter(tempfile)
-	for _, e := range entries {
-		if !isValidPath(e.val) {
-			continue
-		}
-		if _, err := fmt.Fprintln(writer, e); err != nil {
-			return err
-		}
+	if err := write(writer); err != nil {
+		_ = tempfile.Close()
+		return err
 	}
 	if err := writer.Flush(); err != nil {
+		_ = tempfile.Close()
 		return err
 	}
 
@@ -148,7 +504,7 @@ func (s Store) saveEntries(entries EntryList) error {
 		return err
 	}
 
-	if err = os.Rename(tempfile.Name(), s.path); err != nil {
+	if err = os.Rename(tempfile.Name(), path); err != nil {
 		return err
 	}
 
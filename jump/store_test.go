@@ -2,9 +2,15 @@ package jump
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,23 +22,23 @@ func TestSaveEntries(t *testing.T) {
 	}
 	defer os.RemoveAll(dir)
 
-	rawEntries := []*entry{
-		{filepath.Join(dir, "b"), 10},
-		{filepath.Join(dir, "a"), 20},
-		{filepath.Join(dir, "c"), 15},
+	rawEntries := []*Entry{
+		{Path: filepath.Join(dir, "b"), Score: 10},
+		{Path: filepath.Join(dir, "a"), Score: 20},
+		{Path: filepath.Join(dir, "c"), Score: 15},
 	}
 	for _, e := range rawEntries {
-		err := os.MkdirAll(e.val, 0664)
+		err := os.MkdirAll(e.Path, 0664)
 		assert.Nil(t, err)
 	}
 	// Append a non-exist dir that should be ignored
-	rawEntries = append(rawEntries, &entry{val: "non-exist", score: 15})
+	rawEntries = append(rawEntries, &Entry{Path: "non-exist", Score: 15})
 	entries := EntryList(rawEntries)
 
 	fileName := filepath.Join(dir, "testEntries")
 	store := NewStore(fileName)
 
-	err = store.saveEntries(entries)
+	err = store.saveEntries(entries, nil)
 	assert.Nil(t, err)
 
 	entriesFile, err := os.Open(fileName)
@@ -48,11 +54,11 @@ func TestSaveEntries(t *testing.T) {
 
 	for i, r := range results {
 		assert.Equal(t, entries[i].String(), r)
-		err := os.Remove(entries[i].val)
+		err := os.Remove(entries[i].Path)
 		assert.Nil(t, err)
 	}
 
-	err = store.saveEntries(entries)
+	err = store.saveEntries(entries, nil)
 	assert.Nil(t, err)
 
 	content, err := os.ReadFile(fileName)
@@ -60,3 +66,357 @@ func TestSaveEntries(t *testing.T) {
 
 	assert.Empty(t, content)
 }
+
+func TestAddPathRecordsVisits(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	origNow := now
+	defer func() { now = origNow }()
+	visitedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return visitedAt }
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, store.AddPath(dir))
+	visitedAt = visitedAt.Add(time.Hour)
+	assert.Nil(t, store.AddPath(dir))
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Visits)
+	assert.True(t, visitedAt.Equal(entries[0].LastVisit))
+	assert.True(t, visitedAt.Add(-time.Hour).Equal(entries[0].FirstSeen))
+}
+
+func TestAddPathConcurrently(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const nPaths = 20
+	var paths []string
+	for i := 0; i < nPaths; i++ {
+		p := filepath.Join(dir, strconv.Itoa(i))
+		err := os.MkdirAll(p, 0740)
+		assert.Nil(t, err)
+		paths = append(paths, p)
+	}
+	store := NewStore(filepath.Join(dir, "testEntries"))
+
+	var wg sync.WaitGroup
+	for _, p := range paths {
+		wg.Add(1)
+		go func(p string) {
+			defer wg.Done()
+			assert.Nil(t, store.AddPath(p))
+		}(p)
+	}
+	wg.Wait()
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	var saved []string
+	for _, e := range entries {
+		saved = append(saved, e.Path)
+	}
+	assert.ElementsMatch(t, paths, saved, "Some visits are lost")
+}
+
+func TestAddPathFromManyProcesses(t *testing.T) {
+	if os.Getenv("SHONENJUMP_TEST_DATA_PATH") != "" {
+		t.Skip("Running as a helper process")
+	}
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dataPath := filepath.Join(dir, "testEntries")
+
+	const nProcesses, nVisits = 8, 10
+	var wg sync.WaitGroup
+	for i := 0; i < nProcesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=TestHelperAddPath")
+			cmd.Env = append(
+				os.Environ(),
+				"SHONENJUMP_TEST_DATA_PATH="+dataPath,
+				"SHONENJUMP_TEST_VISITS="+strconv.Itoa(nVisits),
+			)
+			out, err := cmd.CombinedOutput()
+			assert.Nil(t, err, string(out))
+		}()
+	}
+	wg.Wait()
+
+	// Visiting the same path repeatedly produces the same score no matter how
+	// the visits are interleaved, so any lost update would show up here.
+	var expected EntryList
+	for i := 0; i < nProcesses*nVisits; i++ {
+		expected.Age()
+		expected = expected.Update(dir, DefaultWeight)
+		// Scores are rounded when saved
+		saved, err := ParseEntry(expected[0].String())
+		assert.Nil(t, err)
+		expected[0].Score = saved.Score
+	}
+	entries, err := NewStore(dataPath).ReadEntries()
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, expected[0].Score, entries[0].Score)
+}
+
+// TestHelperAddPath is run by TestAddPathFromManyProcesses in a subprocess.
+func TestHelperAddPath(t *testing.T) {
+	dataPath := os.Getenv("SHONENJUMP_TEST_DATA_PATH")
+	if dataPath == "" {
+		t.Skip("Only used as a helper process")
+	}
+	visits, err := strconv.Atoi(os.Getenv("SHONENJUMP_TEST_VISITS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(dataPath)
+	for i := 0; i < visits; i++ {
+		if err := store.AddPath(filepath.Dir(dataPath)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAddPathLockTimeout(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	store.lockTimeout = 50 * time.Millisecond
+
+	lock, err := acquireLock(store.path+".lock", time.Second)
+	assert.Nil(t, err)
+	defer lock.Close()
+
+	err = store.AddPath(dir)
+	assert.True(t, errors.Is(err, ErrLockTimeout), "Expected lock timeout, got %v", err)
+}
+
+func TestRemove(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths := []string{
+		filepath.Join(dir, "projects", "shonenjump"),
+		filepath.Join(dir, "projects", "autojump"),
+		filepath.Join(dir, "music"),
+	}
+	setup := func(t *testing.T) Store {
+		store := NewStore(filepath.Join(dir, "testEntries"))
+		for _, p := range paths {
+			assert.Nil(t, os.MkdirAll(p, 0740))
+			assert.Nil(t, store.AddPath(p))
+		}
+		return store
+	}
+	remaining := func(t *testing.T, store Store) []string {
+		entries, err := store.ReadEntries()
+		assert.Nil(t, err)
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Path)
+		}
+		return result
+	}
+
+	t.Run("Should remove an exact path", func(t *testing.T) {
+		store := setup(t)
+		removed, err := store.Remove([]string{paths[2]}, nil)
+		assert.Nil(t, err)
+		assert.Len(t, removed, 1)
+		assert.Equal(t, paths[2], removed[0].Path)
+		assert.ElementsMatch(t, paths[:2], remaining(t, store))
+	})
+
+	t.Run("Should remove the current directory", func(t *testing.T) {
+		store := setup(t)
+		wd, err := os.Getwd()
+		assert.Nil(t, err)
+		defer func() { assert.Nil(t, os.Chdir(wd)) }()
+		assert.Nil(t, os.Chdir(paths[0]))
+
+		removed, err := store.Remove([]string{"."}, nil)
+		assert.Nil(t, err)
+		assert.Len(t, removed, 1)
+		assert.ElementsMatch(t, paths[1:], remaining(t, store))
+	})
+
+	t.Run("Should remove paths matching a query", func(t *testing.T) {
+		store := setup(t)
+		removed, err := store.Remove([]string{"proj", "jump"}, nil)
+		assert.Nil(t, err)
+		assert.Len(t, removed, 2)
+		assert.Equal(t, []string{paths[2]}, remaining(t, store))
+	})
+
+	t.Run("Should only remove paths matching a query closely", func(t *testing.T) {
+		store := setup(t)
+		// The fuzzy matcher would find both projects
+		removed, err := store.Remove([]string{"jp"}, nil)
+		assert.Nil(t, err)
+		assert.Empty(t, removed)
+		assert.ElementsMatch(t, paths, remaining(t, store))
+	})
+
+	t.Run("Should only remove confirmed paths", func(t *testing.T) {
+		store := setup(t)
+		var confirmed EntryList
+		removed, err := store.Remove([]string{"jump"}, func(entries EntryList) bool {
+			confirmed = entries
+			return false
+		})
+		assert.Nil(t, err)
+		assert.Empty(t, removed)
+		assert.Len(t, confirmed, 2)
+		assert.ElementsMatch(t, paths, remaining(t, store))
+
+		removed, err = store.Remove([]string{"jump"}, func(entries EntryList) bool {
+			return true
+		})
+		assert.Nil(t, err)
+		assert.Len(t, removed, 2)
+		assert.Equal(t, []string{paths[2]}, remaining(t, store))
+	})
+
+	t.Run("Should not touch the data file if nothing matches", func(t *testing.T) {
+		store := setup(t)
+		removed, err := store.Remove([]string{"/non/exist"}, nil)
+		assert.Nil(t, err)
+		assert.Empty(t, removed)
+		assert.ElementsMatch(t, paths, remaining(t, store))
+	})
+}
+
+func TestAdjust(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for _, p := range []string{a, b} {
+		assert.Nil(t, os.Mkdir(p, 0740))
+	}
+	store := NewStore(filepath.Join(dir, "testEntries"))
+	assert.Nil(t, store.AddPath(a))
+
+	ent, err := store.Adjust(b, 30)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(30), ent.Score)
+	assert.Equal(t, 1, ent.Visits, "Increasing counts as a visit")
+	assert.False(t, ent.LastVisit.IsZero())
+
+	ent, err = store.Adjust(a, -5)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultWeight-5, ent.Score, "Other entries should not be aged")
+	assert.Equal(t, 1, ent.Visits, "Decreasing is not a visit")
+
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, b, entries[0].Path)
+	assert.Equal(t, float64(30), entries[0].Score)
+	assert.Equal(t, DefaultWeight-5, entries[1].Score)
+
+	_, err = store.Adjust(filepath.Join(dir, "c"), -5)
+	assert.NotNil(t, err, "Paths not in database can't be decreased")
+}
+
+func TestMove(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := func(p string) string { return filepath.Join(dir, p) }
+	for _, p := range []string{"new/api", "new/web", "apps"} {
+		assert.Nil(t, os.MkdirAll(path(p), 0740))
+	}
+	store := NewStore(path("testEntries"))
+	entries := EntryList{
+		{Path: path("old"), Score: 30, Visits: 3},
+		{Path: path("old/api"), Score: 40, Visits: 4},
+		{Path: path("old/web"), Score: 20, Visits: 2},
+		{Path: path("new/web"), Score: 15, Visits: 1},
+		{Path: path("apps"), Score: 10, Visits: 1},
+	}
+	// Paths under the old prefix no longer exist, so they can't be saved the usual way
+	f, err := os.Create(path("testEntries"))
+	assert.Nil(t, err)
+	for _, e := range entries {
+		_, err := fmt.Fprintln(f, e)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, f.Close())
+
+	moved, err := store.Move(path("old"), path("new")+"/")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, moved)
+
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(entries))
+	assert.Nil(t, entries.find(path("old")))
+	assert.Equal(t, 30.0, entries.find(path("new")).Score)
+	assert.Equal(t, 40.0, entries.find(path("new/api")).Score)
+	web := entries.find(path("new/web"))
+	assert.Equal(t, 25.0, web.Score, "Scores should be combined like visits")
+	assert.Equal(t, 3, web.Visits)
+	assert.Equal(t, 10.0, entries.find(path("apps")).Score)
+
+	moved, err = store.Move(path("old"), path("new"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, moved)
+
+	_, err = store.Move(path("new"), path("missing"))
+	assert.NotNil(t, err, "Paths can't be moved to a missing directory")
+	_, err = store.Move(path("new"), path("new"))
+	assert.NotNil(t, err)
+
+	// Frecency scores count visits, so they are summed
+	store = NewStore(path("frecencyEntries"), WithScorer(FrecencyScorer{}))
+	assert.Nil(t, os.WriteFile(store.path, []byte(fmt.Sprintf("3\t%s\n2\t%s\n", path("apps"), path("new/api"))), 0640))
+	moved, err = store.Move(path("new/api"), path("apps"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, moved)
+	entries, err = store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, 5.0, entries[0].Score)
+}
+
+func TestAddPathWithWeight(t *testing.T) {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "testEntries"), WithWeight(50))
+	assert.Nil(t, store.AddPath(dir))
+	entries, err := store.ReadEntries()
+	assert.Nil(t, err)
+	assert.Equal(t, float64(50), entries[0].Score)
+}
@@ -0,0 +1,191 @@
+package jump
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scores of subsequence matches, the same as the ones of fzf
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	// bonusBoundary is for a character following a separator, like "b" in "foo-bar"
+	bonusBoundary = scoreMatch / 2
+	// bonusBoundaryDelimiter is for the first character of a directory name
+	bonusBoundaryDelimiter = bonusBoundary + 1
+	// bonusCamel123 is for an upper case character following a lower case one,
+	// or a digit following a letter, like "B" in "fooBar"
+	bonusCamel123 = bonusBoundary + scoreGapExtension
+	// bonusNonWord is for matching a separator itself
+	bonusNonWord = scoreMatch / 2
+	// bonusConsecutive is for every character following a matched one
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+	// bonusFirstCharMultiplier makes the bonus of the first character count more
+	bonusFirstCharMultiplier = 2
+)
+
+type charClass int
+
+const (
+	charNonWord charClass = iota
+	charDelimiter
+	charLower
+	charUpper
+	charLetter
+	charNumber
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case r == os.PathSeparator || r == '/':
+		return charDelimiter
+	case unicode.IsLower(r):
+		return charLower
+	case unicode.IsUpper(r):
+		return charUpper
+	case unicode.IsLetter(r):
+		return charLetter
+	case unicode.IsNumber(r):
+		return charNumber
+	}
+	return charNonWord
+}
+
+// bonusFor is the bonus of matching a character of class cur following one of class prev.
+func bonusFor(prev, cur charClass) int {
+	if cur > charNonWord && cur != charDelimiter {
+		switch {
+		case prev == charDelimiter:
+			return bonusBoundaryDelimiter
+		case prev == charNonWord:
+			return bonusBoundary
+		case prev == charLower && cur == charUpper,
+			prev != charNumber && cur == charNumber:
+			return bonusCamel123
+		}
+		return 0
+	}
+	return bonusNonWord
+}
+
+// subsequenceScore scores text as a match of pattern, whose characters have to
+// appear in text in the same order but not necessarily next to each other.
+// Matches at the start of words and runs of consecutive characters score higher,
+// gaps between the matched characters lower the score.
+// The match ignores case unless pattern contains upper case characters.
+// ok is false if pattern isn't a subsequence of text.
+func subsequenceScore(pattern, text string) (score int, ok bool) {
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) == 0 || len(p) > len(t) {
+		return 0, len(p) == 0
+	}
+	caseSensitive := strings.IndexFunc(pattern, unicode.IsUpper) != -1
+	if !isSubsequence(p, t, caseSensitive) {
+		return 0, false
+	}
+
+	bonus := make([]int, len(t))
+	prev := charDelimiter
+	for j, r := range t {
+		cur := classOf(r)
+		bonus[j] = bonusFor(prev, cur)
+		prev = cur
+	}
+
+	const none = math.MinInt32
+	// last[j] is the best score of the pattern so far with its last character at t[j]
+	last := make([]int, len(t))
+	current := make([]int, len(t))
+	for i, pr := range p {
+		if !caseSensitive {
+			pr = unicode.ToLower(pr)
+		}
+		// gap is the best score of the previous characters followed by a gap before t[j]
+		gap := none
+		for j, tr := range t {
+			if j >= 2 && i > 0 && last[j-2] != none {
+				gap = maxInt(gap, last[j-2]+scoreGapStart)
+			}
+			if !caseSensitive {
+				tr = unicode.ToLower(tr)
+			}
+			if tr != pr {
+				current[j] = none
+			} else if i == 0 {
+				current[j] = scoreMatch + bonus[j]*bonusFirstCharMultiplier
+			} else {
+				best := gap
+				if j > 0 && last[j-1] != none {
+					best = maxInt(best, last[j-1]+bonusConsecutive)
+				}
+				if best == none {
+					current[j] = none
+				} else {
+					current[j] = best + scoreMatch + bonus[j]
+				}
+			}
+			if gap != none {
+				gap += scoreGapExtension
+			}
+		}
+		last, current = current, last
+	}
+
+	score = none
+	for _, s := range last {
+		score = maxInt(score, s)
+	}
+	return score, score != none
+}
+
+// isSubsequence is a quick check before scoring a match.
+func isSubsequence(p, t []rune, caseSensitive bool) bool {
+	i := 0
+	for _, r := range t {
+		if i == len(p) {
+			break
+		}
+		if r == p[i] || !caseSensitive && unicode.ToLower(r) == unicode.ToLower(p[i]) {
+			i++
+		}
+	}
+	return i == len(p)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// matchSubsequence finds the paths containing the characters of args in order,
+// anywhere in the path, from the best to the worst scored match.
+var matchSubsequence = func(entries EntryList, args []string) []string {
+	pattern := strings.Join(args, "")
+	type scoredPath struct {
+		path  string
+		score int
+	}
+	var matches []scoredPath
+	for _, e := range entries {
+		if score, ok := subsequenceScore(pattern, e.Path); ok {
+			matches = append(matches, scoredPath{e.Path, score})
+		}
+	}
+	// Keep the order of entries for equal scores
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = m.path
+	}
+	return paths
+}
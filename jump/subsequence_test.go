@@ -0,0 +1,100 @@
+package jump
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubsequenceScore(t *testing.T) {
+	_, ok := subsequenceScore("abc", "/acb")
+	assert.False(t, ok, "Characters have to be in order")
+	_, ok = subsequenceScore("abcd", "/abc")
+	assert.False(t, ok)
+	_, ok = subsequenceScore("ABC", "/abc")
+	assert.False(t, ok, "Upper case patterns are case sensitive")
+
+	score, ok := subsequenceScore("abc", "/ABC")
+	assert.True(t, ok, "Lower case patterns ignore case")
+	assert.Equal(t, 3*scoreMatch+bonusBoundaryDelimiter*bonusFirstCharMultiplier+2*bonusConsecutive, score)
+
+	score, ok = subsequenceScore("", "/abc")
+	assert.True(t, ok)
+	assert.Equal(t, 0, score)
+
+	// Pairs of a better and a worse match
+	cases := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"src", "/home/src", "/home/sxrxc"},
+		{"web", "/src/web", "/src/aweb"},
+		{"mr", "/src/my-repo", "/src/mirror"},
+		{"mr", "/src/my_repo", "/src/mirror"},
+		{"fb", "/src/fooBar", "/src/foobar"},
+		{"v2", "/src/app/v2", "/src/app/v12"},
+		{"srcweb", "/src/web", "/srcx/web"},
+		{"sjp", "/src/shonenjump/pkg", "/src/shonen/tmp/jump/apkg"},
+	}
+	for _, c := range cases {
+		better, ok := subsequenceScore(c.pattern, c.better)
+		assert.True(t, ok, c.better)
+		worse, ok := subsequenceScore(c.pattern, c.worse)
+		assert.True(t, ok, c.worse)
+		assert.Greater(t, better, worse, "%s should match %s better than %s", c.pattern, c.better, c.worse)
+	}
+}
+
+func TestSubsequenceScoreTakesBestAlignment(t *testing.T) {
+	// The first "a" isn't at a boundary, the second one is
+	score, ok := subsequenceScore("ab", "/xa/ab")
+	assert.True(t, ok)
+	assert.Equal(t, 2*scoreMatch+bonusBoundaryDelimiter*bonusFirstCharMultiplier+bonusConsecutive, score)
+}
+
+func TestMatchSubsequence(t *testing.T) {
+	entries := EntryList{
+		{Path: "/home/user/sxrxc", Score: 30},
+		{Path: "/home/user/src", Score: 20},
+		{Path: "/home/user/documents", Score: 10},
+		{Path: "/home/user/scratch/rc", Score: 5},
+	}
+	assert.Equal(t,
+		[]string{"/home/user/src", "/home/user/scratch/rc", "/home/user/sxrxc", "/home/user/documents"},
+		matchSubsequence(entries, []string{"src"}),
+	)
+	assert.Equal(t,
+		[]string{"/home/user/scratch/rc"},
+		matchSubsequence(entries, []string{"scr", "rc"}),
+		"Keywords can match across path components",
+	)
+	assert.Empty(t, matchSubsequence(entries, []string{"zzz"}))
+}
+
+func TestFinderWithSubsequenceMatcher(t *testing.T) {
+	orig := isValidPath
+	defer func() { isValidPath = orig }()
+	isValidPath = func(p string) bool {
+		return true
+	}
+
+	finder, err := NewFinder([]string{MatchExactName, MatchSubsequence})
+	assert.Nil(t, err)
+	entries := EntryList{
+		{Path: "/src/my-repo", Score: 10},
+		{Path: "/src/mirror", Score: 20},
+	}
+	assert.Equal(t, []Candidate{
+		{"/src/my-repo", 10, MatchSubsequence},
+		{"/src/mirror", 20, MatchSubsequence},
+	}, finder.Find(entries, []string{"mr"}, 9))
+}
+
+func BenchmarkMatchSubsequence(b *testing.B) {
+	entries := generateManyEntries(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchSubsequence(entries, []string{"proj4", "mod"})
+	}
+}
@@ -2,11 +2,20 @@ package jump
 
 import (
 	"os"
+	"strings"
+	"time"
 )
 
+var now = time.Now
+
 var isValidPath = func(p string) bool {
 	if _, err := os.Stat(p); os.IsNotExist(err) {
 		return false
 	}
 	return true
 }
+
+// isPathArg tells if the argument refers to a path rather than a query.
+func isPathArg(arg string) bool {
+	return arg == "." || arg == ".." || strings.ContainsRune(arg, os.PathSeparator)
+}
@@ -1,13 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"os/user"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -21,19 +21,6 @@ const (
 	separator = "__"
 )
 
-func ensureDataPath() string {
-	dir := os.Getenv("XDG_DATA_HOME")
-	if dir == "" {
-		usr, _ := user.Current()
-		dir = filepath.Join(usr.HomeDir, ".local/share")
-	}
-	dir = filepath.Join(dir, "shonenjump")
-	if err := os.MkdirAll(dir, 0740); err != nil {
-		panic(err)
-	}
-	return filepath.Join(dir, "shonenjump.txt")
-}
-
 func parseCompleteOption(s string) (needle string, index int, path string) {
 	parts := strings.SplitN(s, separator, 3)
 	n := len(parts)
@@ -54,17 +41,203 @@ func parseCompleteOption(s string) (needle string, index int, path string) {
 	return
 }
 
+// weightFlag is a flag taking an optional weight,
+// so that both `--increase` and `--increase=30` work.
+type weightFlag struct {
+	set    bool
+	weight float64
+}
+
+func (f *weightFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(f.weight, 'f', -1, 64)
+}
+
+func (f *weightFlag) Set(s string) error {
+	switch s {
+	case "true":
+		f.set = true
+	case "false":
+		f.set = false
+	default:
+		weight, err := strconv.ParseFloat(s, 64)
+		if err != nil || weight <= 0 {
+			return fmt.Errorf("invalid weight: %v", s)
+		}
+		f.set = true
+		f.weight = weight
+	}
+	return nil
+}
+
+func (f *weightFlag) IsBoolFlag() bool {
+	return true
+}
+
+// parseAdjustArgs handles the arguments following `--increase` or `--decrease`,
+// which are an optional weight and an optional path in this order.
+func parseAdjustArgs(args []string, weight float64) (float64, string) {
+	if len(args) > 0 {
+		if w, err := strconv.ParseFloat(args[0], 64); err == nil && w > 0 {
+			weight = w
+			args = args[1:]
+		}
+	}
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	return weight, path
+}
+
+// adjustFlags returns the weight and path given to --increase or --decrease,
+// the weight being negative for --decrease.
+func adjustFlags(increase, decrease *weightFlag, args []string) (float64, string, error) {
+	if increase.set && decrease.set {
+		return 0, "", fmt.Errorf("only one of --increase and --decrease can be given")
+	}
+	if decrease.set {
+		weight, path := parseAdjustArgs(args, decrease.weight)
+		return -weight, path, nil
+	}
+	weight, path := parseAdjustArgs(args, increase.weight)
+	return weight, path, nil
+}
+
+// scopeFlag tells which of --child, --parent and --sibling was given, if any.
+func scopeFlag(child, parent, sibling bool) (string, error) {
+	var scopes []string
+	if child {
+		scopes = append(scopes, jump.ScopeChild)
+	}
+	if parent {
+		scopes = append(scopes, jump.ScopeParent)
+	}
+	if sibling {
+		scopes = append(scopes, jump.ScopeSibling)
+	}
+	switch len(scopes) {
+	case 0:
+		return "", nil
+	case 1:
+		return scopes[0], nil
+	}
+	return "", fmt.Errorf("only one of --child, --parent and --sibling can be given")
+}
+
+// command runs a subcommand, like `shonenjump config show`,
+// with the arguments following its name.
+type command func(cfg config, args []string) error
+
+var commands = map[string]command{
+	"config": runConfig,
+	"import": runImport,
+	"export": runExport,
+	"merge":  runMerge,
+	"move":   runMove,
+	"pin":    runPin,
+	"unpin":  runUnpin,
+	"pins":   runPins,
+	"init":   runInit,
+	"daemon": runDaemon,
+	"doctor": runDoctor,
+}
+
+// modeFlags are the flags choosing what to do with the positional arguments,
+// which are then never taken for a subcommand.
+var modeFlags = []string{
+	"add", "complete", "purge", "remove", "stat", "increase", "decrease", "version",
+	"interactive", "i", "explain", "child", "parent", "sibling", "format",
+}
+
+// subcommand returns the subcommand named by the first positional argument of fs,
+// which was parsed from args. Keywords following "--" or a mode flag aren't subcommands.
+func subcommand(fs *flag.FlagSet, args []string) (command, bool) {
+	if fs.NArg() == 0 {
+		return nil, false
+	}
+	if i := len(args) - fs.NArg() - 1; i >= 0 && args[i] == "--" {
+		return nil, false
+	}
+	var modeSet bool
+	fs.Visit(func(f *flag.Flag) {
+		if contains(modeFlags, f.Name) {
+			modeSet = true
+		}
+	})
+	if modeSet {
+		return nil, false
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	return cmd, ok
+}
+
 func main() {
 	pathToAdd := flag.String("add", "", "Add this path")
 	complete := flag.Bool("complete", false, "Used for tab completion")
 	purge := flag.Bool("purge", false, "Remove non-existent paths from database")
+	purgeExcluded := flag.Bool("excluded", false, "Used with --purge to also remove excluded paths")
+	remove := flag.String("remove", "", "Remove a path, or the paths matching a query, from database")
+	yes := flag.Bool("yes", false, "Used with --remove to remove paths without asking for confirmation")
 	stat := flag.Bool("stat", false, "Show information about recorded paths")
+	increase := &weightFlag{weight: 10}
+	flag.Var(increase, "increase", "Increase the weight of current or given directory")
+	decrease := &weightFlag{weight: 15}
+	flag.Var(decrease, "decrease", "Decrease the weight of current or given directory")
 	ver := flag.Bool("version", false, "Show version of shonenjump")
+	var interactive bool
+	flag.BoolVar(&interactive, "interactive", false, "Choose the directory to jump to interactively")
+	flag.BoolVar(&interactive, "i", false, "Shorthand for --interactive")
+	configPath := flag.String("config", "", "Use this config file")
+	explain := flag.Bool("explain", false, "Show how the directories matching a query are ranked")
+	child := flag.Bool("child", false, "Only look for subdirectories of the current directory")
+	parent := flag.Bool("parent", false, "Only look for parents of the current directory")
+	sibling := flag.Bool("sibling", false, "Only look for directories beside the current directory")
+	format := flag.String("format", "", "Print results of --stat, --complete and queries in this format: json, jsonl, tsv or csv")
 	flag.Parse()
-	dataPath := ensureDataPath()
-	store := jump.NewStore(dataPath)
+	if *format != "" && !isValidFormat(*format) {
+		log.Fatalf("unknown format: %q", *format)
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cmd, ok := subcommand(flag.CommandLine, os.Args[1:]); ok {
+		if err := cmd(cfg, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	finder, err := cfg.finder()
+	if err != nil {
+		log.Fatal(err)
+	}
+	scope, err := scopeFlag(*child, *parent, *sibling)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if scope != "" {
+		if finder, err = finder.WithScope(scope, "."); err != nil {
+			log.Fatal(err)
+		}
+	}
+	store, err := cfg.newStore(jump.WithFinder(finder))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Paths are added on every prompt, so they shouldn't pay for reading the pins
+	if *pathToAdd == "" {
+		pins, err := store.ReadPins()
+		if err != nil {
+			log.Fatal(err)
+		}
+		finder = finder.WithPins(pins)
+	}
+	lookup := lookup{store: store, finder: finder, useDaemon: scope == ""}
 	if *pathToAdd != "" {
-		if err := store.AddPath(*pathToAdd); err != nil {
+		if err := lookup.addPath(*pathToAdd); err != nil {
 			log.Fatal(err)
 		}
 	} else if *complete {
@@ -73,19 +246,107 @@ func main() {
 		if len(args) > 0 {
 			arg = args[0]
 		}
-		showAutoCompleteOptions(store, arg)
+		if *format != "" {
+			needle, _, _ := parseCompleteOption(arg)
+			printCandidates(store, finder.Completing(), []string{needle}, cfg.MaxCompleteOptions, *format)
+		} else {
+			showAutoCompleteOptions(lookup, arg, cfg.MaxCompleteOptions)
+		}
 	} else if *purge {
 		if err := store.Cleanup(); err != nil {
 			log.Fatal(err)
 		}
+		if *purgeExcluded {
+			removed, err := store.PurgeExcluded()
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, e := range removed {
+				fmt.Printf("Removed %s\n", e.Path)
+			}
+		}
+	} else if *remove != "" {
+		var asked bool
+		confirm := func(entries jump.EntryList) bool {
+			asked = true
+			return *yes || confirmRemoval(entries)
+		}
+		removed, err := store.Remove(append([]string{*remove}, flag.Args()...), confirm)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(removed) == 0 && !asked {
+			fmt.Println("Nothing to remove")
+		}
+		for _, e := range removed {
+			fmt.Printf("Removed %s\n", e.Path)
+		}
+	} else if increase.set || decrease.set {
+		weight, path, err := adjustFlags(increase, decrease, flag.Args())
+		if err != nil {
+			log.Fatal(err)
+		}
+		ent, err := store.Adjust(path, weight)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(ent)
 	} else if *stat {
 		entries, err := store.ReadEntries()
 		if err != nil {
 			log.Fatal(err)
 		}
-		printEntries(entries)
+		if *format != "" {
+			if err := writeRecords(os.Stdout, *format, entryRecords(entries)); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			printEntries(entries)
+		}
+	} else if interactive {
+		path, err := runPicker(store, finder, strings.Join(flag.Args(), " "))
+		if errors.Is(err, errPickerCanceled) {
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(path)
+	} else if *explain {
+		entries, err := store.ReadEntries()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := printExplanations(os.Stdout, finder.Explain(entries, flag.Args())); err != nil {
+			log.Fatal(err)
+		}
 	} else if *ver {
 		fmt.Println(version)
+	} else if flag.NArg() > 0 && *format != "" {
+		args := flag.Args()
+		index := 1
+		if len(args) == 1 {
+			var needle string
+			needle, index, _ = parseCompleteOption(args[0])
+			args = []string{needle}
+			if index == 0 {
+				index = 1
+			}
+		}
+		// Only print the chosen candidate, like a regular query does
+		candidates := findCandidates(store, finder, args, index)
+		if len(candidates) == index {
+			candidates = candidates[index-1:]
+		} else {
+			candidates = nil
+		}
+		records := candidateRecords(candidates)
+		for i := range records {
+			records[i].Rank = index
+		}
+		if err := writeRecords(os.Stdout, *format, records); err != nil {
+			log.Fatal(err)
+		}
 	} else if flag.NArg() > 0 {
 		args := flag.Args()
 		if len(args) == 1 {
@@ -95,7 +356,7 @@ func main() {
 				return
 			}
 			if index != 0 {
-				path, err := store.GetNthCandidate([]string{needle}, index, ".")
+				path, err := lookup.nthCandidate([]string{needle}, index, ".")
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -104,11 +365,18 @@ func main() {
 			}
 			args = []string{needle}
 		}
-		entries, err := store.ReadEntries()
+		path, err := lookup.bestGuess(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(path)
+	} else if scope != "" {
+		// The top path of the scope rather than of the whole database
+		path, err := lookup.bestGuess(nil)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Println(jump.BestGuess(entries, args))
+		fmt.Println(path)
 	} else {
 		path, err := store.GetTopPath(".")
 		if err != nil {
@@ -151,12 +419,47 @@ func printEntries(entries jump.EntryList) {
 	<-writeComplete
 }
 
-func showAutoCompleteOptions(store jump.Store, arg string) {
+// confirmRemoval lists the entries about to be removed and asks whether to go on.
+// It refuses when there is no terminal to ask on.
+func confirmRemoval(entries jump.EntryList) bool {
+	for _, e := range entries {
+		fmt.Fprintf(os.Stderr, "%s\n", e.Path)
+	}
+	stdin := os.Stdin
+	if !isatty.IsTerminal(stdin.Fd()) && !isatty.IsCygwinTerminal(stdin.Fd()) {
+		fmt.Fprintln(os.Stderr, "Not removed, run with --yes to remove without confirmation")
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Remove %d paths? [y/N] ", len(entries))
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func findCandidates(store jump.Store, finder jump.Finder, args []string, limit int) []jump.Candidate {
+	entries, err := store.ReadEntries()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return finder.Find(entries, args, limit)
+}
+
+func printCandidates(store jump.Store, finder jump.Finder, args []string, limit int, format string) {
+	candidates := findCandidates(store, finder, args, limit)
+	if err := writeRecords(os.Stdout, format, candidateRecords(candidates)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func showAutoCompleteOptions(lookup lookup, arg string, limit int) {
 	needle, index, path := parseCompleteOption(arg)
 	if path != "" {
 		fmt.Println(path)
 	} else if index != 0 {
-		path, err := store.GetNthCandidate([]string{needle}, index, "")
+		path, err := lookup.nthCandidate([]string{needle}, index, "")
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -164,11 +467,10 @@ func showAutoCompleteOptions(store jump.Store, arg string) {
 			fmt.Println(path)
 		}
 	} else {
-		entries, err := store.ReadEntries()
+		candidates, err := lookup.completions([]string{needle}, limit)
 		if err != nil {
 			log.Fatal(err)
 		}
-		candidates := jump.GetCandidates(entries, []string{needle}, jump.MaxCompleteOptions)
 		var sb strings.Builder
 		for i, path := range candidates {
 			sb.Reset()
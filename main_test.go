@@ -1,9 +1,12 @@
 package main
 
 import (
+	"flag"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/suzaku/shonenjump/jump"
 )
 
 func TestParseCompleteOption(t *testing.T) {
@@ -25,3 +28,98 @@ func TestParseCompleteOption(t *testing.T) {
 		assert.Equal(t, test.path, path)
 	}
 }
+
+func TestParseAdjustArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		weight float64
+		path   string
+	}{
+		{nil, 10, "."},
+		{[]string{"30"}, 30, "."},
+		{[]string{"30", "/tmp"}, 30, "/tmp"},
+		{[]string{"/tmp"}, 10, "/tmp"},
+	}
+	for _, test := range tests {
+		weight, path := parseAdjustArgs(test.args, 10)
+		assert.Equal(t, test.weight, weight)
+		assert.Equal(t, test.path, path)
+	}
+}
+
+func TestAdjustFlags(t *testing.T) {
+	increase := &weightFlag{weight: 10}
+	decrease := &weightFlag{set: true, weight: 15}
+	weight, path, err := adjustFlags(increase, decrease, []string{"/tmp"})
+	assert.Nil(t, err)
+	assert.Equal(t, float64(-15), weight)
+	assert.Equal(t, "/tmp", path)
+
+	increase.set = true
+	_, _, err = adjustFlags(increase, decrease, nil)
+	assert.NotNil(t, err)
+}
+
+func TestWeightFlag(t *testing.T) {
+	f := &weightFlag{weight: 10}
+	assert.Nil(t, f.Set("true"))
+	assert.True(t, f.set)
+	assert.Equal(t, float64(10), f.weight)
+
+	assert.Nil(t, f.Set("25"))
+	assert.Equal(t, float64(25), f.weight)
+
+	assert.NotNil(t, f.Set("-1"))
+	assert.NotNil(t, f.Set("abc"))
+}
+
+func TestRewritesFlag(t *testing.T) {
+	var f rewritesFlag
+	assert.Nil(t, f.Set("/home/alice=/Users/alice"))
+	assert.Nil(t, f.Set("/mnt/data/=/data"))
+	assert.Equal(t, "/home/alice=/Users/alice,/mnt/data=/data", f.String())
+
+	assert.NotNil(t, f.Set("/home/alice"))
+	assert.Equal(t, 2, len(f))
+}
+
+func TestScopeFlag(t *testing.T) {
+	scope, err := scopeFlag(false, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "", scope)
+
+	scope, err = scopeFlag(false, true, false)
+	assert.Nil(t, err)
+	assert.Equal(t, jump.ScopeParent, scope)
+
+	_, err = scopeFlag(true, false, true)
+	assert.NotNil(t, err)
+}
+
+func TestSubcommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"init", "bash"}, true},
+		{[]string{"--config", "shonenjump.toml", "doctor"}, true},
+		{[]string{"--", "init"}, false},
+		{[]string{"--complete", "init"}, false},
+		// Completing a keyword mustn't start a daemon running until it is stopped
+		{[]string{"--complete", "daemon"}, false},
+		{[]string{"--explain", "import"}, false},
+		{[]string{"-i", "config"}, false},
+		{[]string{"proj"}, false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("shonenjump", flag.ContinueOnError)
+		fs.String("config", "", "")
+		fs.Bool("complete", false, "")
+		fs.Bool("explain", false, "")
+		fs.Bool("i", false, "")
+		assert.Nil(t, fs.Parse(test.args))
+		_, ok := subcommand(fs, test.args)
+		assert.Equal(t, test.ok, ok, "%v", test.args)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+// rewritesFlag collects the rewrites given with every --rewrite.
+type rewritesFlag []jump.Rewrite
+
+func (f *rewritesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	var parts []string
+	for _, r := range *f {
+		parts = append(parts, r.Old+"="+r.New)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *rewritesFlag) Set(s string) error {
+	r, err := jump.ParseRewrite(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, r)
+	return nil
+}
+
+func runMerge(cfg config, args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	var rewrites rewritesFlag
+	flags.Var(&rewrites, "rewrite", "Rewrite paths starting with old to start with new, given as old=new")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: shonenjump merge [--rewrite old=new]... <other-db>")
+	}
+
+	// A missing data file is just empty for a store, but not here
+	otherPath := flags.Arg(0)
+	if _, err := os.Stat(otherPath); err != nil {
+		return err
+	}
+	other, err := jump.NewStore(otherPath).ReadEntries()
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	stats, err := store.Merge(other, rewrites)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %d, merged %d, skipped %d\n", stats.Added, stats.Merged, stats.Skipped)
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+)
+
+func runMove(cfg config, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: shonenjump move <old-prefix> <new-prefix>")
+	}
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	moved, err := store.Move(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved %d entries\n", moved)
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/suzaku/shonenjump/jump"
+)
+
+// Output formats for tools, selected with --format
+const (
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+	formatTSV   = "tsv"
+	formatCSV   = "csv"
+)
+
+var outputFormats = []string{formatJSON, formatJSONL, formatTSV, formatCSV}
+
+func isValidFormat(format string) bool {
+	return contains(outputFormats, format)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// record is a path printed in one of the output formats.
+type record struct {
+	Path  string  `json:"path"`
+	Score float64 `json:"score"`
+	// Rank is the 1-based position of the path in the results.
+	Rank int `json:"rank"`
+	// Matcher is empty when the path isn't the result of a query.
+	Matcher string `json:"matcher,omitempty"`
+}
+
+func entryRecords(entries jump.EntryList) []record {
+	records := make([]record, len(entries))
+	for i, e := range entries {
+		records[i] = record{Path: e.Path, Score: e.Score, Rank: i + 1}
+	}
+	return records
+}
+
+func candidateRecords(candidates []jump.Candidate) []record {
+	records := make([]record, len(candidates))
+	for i, c := range candidates {
+		records[i] = record{Path: c.Path, Score: c.Score, Rank: i + 1, Matcher: c.Matcher}
+	}
+	return records
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func writeRecords(w io.Writer, format string, records []record) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case formatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatTSV:
+		if _, err := fmt.Fprintln(w, "rank\tscore\tpath\tmatcher"); err != nil {
+			return err
+		}
+		for _, r := range records {
+			// Tabs and newlines in paths are escaped to keep one record per line
+			_, err := fmt.Fprintf(w, "%d\t%.2f\t%s\t%s\n", r.Rank, r.Score, tsvEscaper.Replace(r.Path), r.Matcher)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"rank", "score", "path", "matcher"}); err != nil {
+			return err
+		}
+		for _, r := range records {
+			row := []string{
+				strconv.Itoa(r.Rank),
+				strconv.FormatFloat(r.Score, 'f', 2, 64),
+				r.Path,
+				r.Matcher,
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("unknown format: %q", format)
+}
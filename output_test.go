@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRecords(t *testing.T) {
+	records := []record{
+		{Path: "/home/tester/with\ttab", Score: 20, Rank: 1, Matcher: "exact"},
+		{Path: "/home/tester/with\nnewline, comma", Score: 12.345, Rank: 2, Matcher: "fuzzy"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeRecords(&buf, formatJSON, records))
+		var decoded []record
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, records, decoded)
+	})
+
+	t.Run("json without records", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeRecords(&buf, formatJSON, candidateRecords(nil)))
+		assert.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeRecords(&buf, formatJSONL, records))
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		assert.Len(t, lines, 2)
+		var decoded record
+		assert.Nil(t, json.Unmarshal(lines[1], &decoded))
+		assert.Equal(t, records[1], decoded)
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeRecords(&buf, formatTSV, records))
+		expected := "rank\tscore\tpath\tmatcher\n" +
+			"1\t20.00\t/home/tester/with\\ttab\texact\n" +
+			"2\t12.35\t/home/tester/with\\nnewline, comma\tfuzzy\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeRecords(&buf, formatCSV, records))
+		rows, err := csv.NewReader(&buf).ReadAll()
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"rank", "score", "path", "matcher"},
+			{"1", "20.00", "/home/tester/with\ttab", "exact"},
+			{"2", "12.35", "/home/tester/with\nnewline, comma", "fuzzy"},
+		}, rows)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		assert.NotNil(t, writeRecords(&bytes.Buffer{}, "xml", records))
+		assert.False(t, isValidFormat("xml"))
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+)
+
+func runPin(cfg config, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("usage: shonenjump pin <keyword> [path]")
+	}
+	path := "."
+	if len(args) == 2 {
+		path = args[1]
+	}
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	path, err = store.Pin(args[0], path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pinned %s to %s\n", args[0], path)
+	return nil
+}
+
+func runUnpin(cfg config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: shonenjump unpin <keyword>")
+	}
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	path, err := store.Unpin(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Unpinned %s from %s\n", args[0], path)
+	return nil
+}
+
+func runPins(cfg config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: shonenjump pins")
+	}
+	store, err := cfg.newStore()
+	if err != nil {
+		return err
+	}
+	pins, err := store.ReadPins()
+	if err != nil {
+		return err
+	}
+	for _, k := range pins.Keywords() {
+		fmt.Printf("%s\t%s\n", k, pins[k])
+	}
+	return nil
+}
@@ -28,7 +28,7 @@ EOF
 complete -F _shonenjump j
 
 
-# change pwd hook
+# prompt hook
 shonenjump_add_to_database() {
     if [[ -f "${SHONENJUMP_ERROR_PATH}" ]]; then
         (shonenjump --add "${PWD}" >/dev/null 2>>${SHONENJUMP_ERROR_PATH} &) &>/dev/null
@@ -48,12 +48,18 @@ esac
 
 # default shonenjump command
 j() {
-    if [[ ${1} == -* ]] && [[ ${1} != "--" ]]; then
+    if [[ ${1} == -i ]] || [[ ${1} == --interactive ]]; then
+        output="$(shonenjump ${@})" || return
+    elif [[ ${1} == --child ]] || [[ ${1} == --parent ]] || [[ ${1} == --sibling ]]; then
+        output="$(shonenjump ${1} -- ${@:2})"
+    elif [[ ${1} == -* ]] && [[ ${1} != "--" ]]; then
         shonenjump ${@}
         return
+    else
+        [[ ${1} == "--" ]] && shift
+        output="$(shonenjump -- ${@})"
     fi
 
-    output="$(shonenjump ${@})"
     if [[ -d "${output}" ]]; then
 				if [ -t 1 ]; then  # if stdout is a terminal, use colors
 						echo -e "\\033[31m${output}\\033[0m"
@@ -76,19 +82,23 @@ jc() {
         shonenjump ${@}
         return
     else
-        j ${PWD} ${@}
+        j --child ${@}
     fi
 }
 
 
 # open shonenjump results in file browser
 jo() {
-    if [[ ${1} == -* ]] && [[ ${1} != "--" ]]; then
+    local scope
+    if [[ ${1} == --child ]] || [[ ${1} == --parent ]] || [[ ${1} == --sibling ]]; then
+        scope=${1}
+        shift
+    elif [[ ${1} == -* ]] && [[ ${1} != "--" ]]; then
         shonenjump ${@}
         return
     fi
 
-    output="$(shonenjump ${@})"
+    output="$(shonenjump ${scope} -- ${@})"
     if [[ -d "${output}" ]]; then
         case ${OSTYPE} in
             linux*)
@@ -119,6 +129,6 @@ jco() {
         shonenjump ${@}
         return
     else
-        jo ${PWD} ${@}
+        jo --child ${@}
     fi
 }
@@ -33,26 +33,34 @@ end
 
 # default shonenjump command
 function j
+    set -l output
     switch "$argv"
+        case '-i' '-i *' '--interactive' '--interactive *'
+            set output (shonenjump $argv); or return
+        case '--child' '--child *' '--parent' '--parent *' '--sibling' '--sibling *'
+            set -l scope $argv[1]
+            set -e argv[1]
+            set output (shonenjump $scope -- $argv)
         case '-*' '--*'
             shonenjump $argv
+            return
         case '*'
-            set -l output (shonenjump $argv)
-            # Check for . and attempt a regular cd
-            if test "$output" = "."
-                cd $argv
-            else
-                if test -d "$output"
-                    set_color red
-                    echo $output
-                    set_color normal
-                    cd $output
-                else
-                    __aj_err "shonenjump: directory '"$argv"' not found"
-                    __aj_err "\n$output\n"
-                    __aj_err "Try `shonenjump --help` for more information."
-                end
-            end
+            set output (shonenjump -- $argv)
+    end
+    # Check for . and attempt a regular cd
+    if test "$output" = "."
+        cd $argv
+    else
+        if test -d "$output"
+            set_color red
+            echo $output
+            set_color normal
+            cd $output
+        else
+            __aj_err "shonenjump: directory '"$argv"' not found"
+            __aj_err "\n$output\n"
+            __aj_err "Try `shonenjump --help` for more information."
+        end
     end
 end
 
@@ -63,20 +71,26 @@ function jc
         case '-*'
             j $argv
         case '*'
-            j $PWD $argv
+            j --child $argv
     end
 end
 
 
 # open shonenjump results in file browser
 function jo
-    set -l output (shonenjump $argv)
+    set -l scope
+    switch "$argv"
+        case '--child' '--child *' '--parent' '--parent *' '--sibling' '--sibling *'
+            set scope $argv[1]
+            set -e argv[1]
+    end
+    set -l output (shonenjump $scope -- $argv)
     if test -d "$output"
         switch $OSTYPE
             case 'linux*'
-                xdg-open (shonenjump $argv)
+                xdg-open $output
             case 'darwin*'
-                open (shonenjump $argv)
+                open $output
             case cygwin
                 cygstart "" (cygpath -w -a $PWD)
             case '*'
@@ -96,6 +110,10 @@ function jco
         case '-*'
             j $argv
         case '*'
-            jo $PWD $argv
+            jo --child $argv
     end
 end
+
+
+# enable tab completion
+complete -x -c j -a '(shonenjump --complete (commandline -t))'
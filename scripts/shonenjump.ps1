@@ -0,0 +1,65 @@
+# shonenjump integration for PowerShell
+#
+# To set it up, add this to your profile ($PROFILE):
+#   Invoke-Expression (& { (shonenjump init powershell | Out-String) })
+
+
+# change pwd hook, run by the prompt when the directory changed
+function global:__shonenjump_add {
+    # Only record directories of the file system, not the registry or other drives
+    if ($PWD.Provider.Name -ne 'FileSystem') {
+        return
+    }
+    if ($PWD.ProviderPath -eq $global:__shonenjump_oldpwd) {
+        return
+    }
+    $global:__shonenjump_oldpwd = $PWD.ProviderPath
+    $null = & shonenjump --add $PWD.ProviderPath 2>$null
+}
+
+if ($null -eq $global:__shonenjump_prompt) {
+    $global:__shonenjump_prompt = $function:prompt
+    function global:prompt {
+        __shonenjump_add
+        & $global:__shonenjump_prompt
+    }
+}
+
+
+# jump to the directory that best matches the keywords
+function global:__shonenjump_jump {
+    param([string[]]$Arguments, [string[]]$Query)
+    $output = & shonenjump @Arguments '--' @Query
+    if ($output -and (Test-Path -LiteralPath $output -PathType Container)) {
+        Write-Host $output -ForegroundColor Red
+        Set-Location -LiteralPath $output
+    } else {
+        Write-Error "shonenjump: directory '$Query' not found"
+    }
+}
+
+
+# default shonenjump command
+function global:j {
+    param([Parameter(ValueFromRemainingArguments = $true)][string[]]$Query)
+    __shonenjump_jump -Arguments @() -Query $Query
+}
+
+
+# jump to child directory (subdirectory of current path)
+function global:jc {
+    param([Parameter(ValueFromRemainingArguments = $true)][string[]]$Query)
+    __shonenjump_jump -Arguments @('--child') -Query $Query
+}
+
+
+# enable tab completion
+$__shonenjump_completer = {
+    param($commandName, $parameterName, $wordToComplete, $commandAst, $fakeBoundParameters)
+    & shonenjump --complete $wordToComplete | ForEach-Object {
+        $text = if ($_ -match '\s') { "'$($_ -replace "'", "''")'" } else { $_ }
+        [System.Management.Automation.CompletionResult]::new($text, $_, 'ParameterValue', $_)
+    }
+}
+Register-ArgumentCompleter -CommandName j -ParameterName Query -ScriptBlock $__shonenjump_completer
+Register-ArgumentCompleter -CommandName jc -ParameterName Query -ScriptBlock $__shonenjump_completer